@@ -5,6 +5,7 @@ go 1.25.3
 require (
 	github.com/hashicorp/go-plugin v1.7.0
 	github.com/johnjallday/ori-agent v0.0.5
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 // Keep replace for now until ori-agent is published with correct module name
@@ -27,5 +28,4 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b // indirect
 	google.golang.org/grpc v1.76.0 // indirect
 	google.golang.org/protobuf v1.36.10 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,322 @@
+// Package audio reads the headers of WAV, AIFF and FLAC files: sample rate,
+// bit depth, channels and length, plus the Broadcast Wave (bext) and iXML
+// chunks field recorders and DAWs write into WAV files.
+//
+// Read only looks at headers, so even long recordings are inspected quickly.
+// Decode reads the samples of WAV and FLAC files, and Analyze measures their
+// loudness.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ErrUnsupported is returned for files that are not WAV, AIFF or FLAC
+var ErrUnsupported = errors.New("unsupported audio format")
+
+// Info describes an audio file
+type Info struct {
+	Format     string // WAV, AIFF or FLAC
+	SampleRate int
+	BitDepth   int
+	Channels   int
+	Frames     int64
+	Float      bool
+	BWF        *BWF
+	IXML       *IXML
+
+	// Where the sample data of a WAV file starts and how it is laid out
+	dataOffset int64
+	dataSize   int64
+	blockAlign int
+}
+
+// Duration returns the length of the audio in seconds
+func (i *Info) Duration() float64 {
+	if i.SampleRate == 0 {
+		return 0
+	}
+	return float64(i.Frames) / float64(i.SampleRate)
+}
+
+// BWF holds the Broadcast Wave Format description (bext chunk)
+type BWF struct {
+	Description     string
+	Originator      string
+	OriginatorRef   string
+	OriginationDate string
+	OriginationTime string
+	// TimeReference is the sample position of the file start since midnight
+	TimeReference uint64
+}
+
+// IXML holds the commonly used fields of an iXML chunk
+type IXML struct {
+	Project string `xml:"PROJECT"`
+	Scene   string `xml:"SCENE"`
+	Take    string `xml:"TAKE"`
+	Tape    string `xml:"TAPE"`
+	Note    string `xml:"NOTE"`
+}
+
+// ReadFile reads the header of an audio file on disk
+func ReadFile(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Read reads the header of a WAV, AIFF or FLAC stream
+func Read(r io.ReadSeeker) (*Info, error) {
+	var magic [12]byte
+	if _, err := io.ReadFull(r, magic[:4]); err != nil {
+		return nil, ErrUnsupported
+	}
+
+	if string(magic[:4]) == "fLaC" {
+		return readFLAC(r)
+	}
+
+	if _, err := io.ReadFull(r, magic[4:]); err != nil {
+		return nil, ErrUnsupported
+	}
+	switch {
+	case (string(magic[:4]) == "RIFF" || string(magic[:4]) == "RF64") && string(magic[8:]) == "WAVE":
+		return readWAV(r)
+	case string(magic[:4]) == "FORM" && (string(magic[8:]) == "AIFF" || string(magic[8:]) == "AIFC"):
+		return readAIFF(r, string(magic[8:]) == "AIFC")
+	}
+	return nil, ErrUnsupported
+}
+
+// chunkHeader is the ID and size of a RIFF or IFF chunk
+type chunkHeader struct {
+	ID   string
+	Size int64
+}
+
+// readChunkHeader reads the next chunk header in the given byte order
+func readChunkHeader(r io.Reader, order binary.ByteOrder) (chunkHeader, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return chunkHeader{}, err
+	}
+	return chunkHeader{ID: string(buf[:4]), Size: int64(order.Uint32(buf[4:]))}, nil
+}
+
+// skipChunk moves past the rest of a chunk, including the pad byte of odd-sized chunks
+func skipChunk(r io.Seeker, size int64) error {
+	_, err := r.Seek(size+size%2, io.SeekCurrent)
+	return err
+}
+
+// readWAV reads the chunks of a RIFF or RF64 WAVE file
+func readWAV(r io.ReadSeeker) (*Info, error) {
+	info := &Info{Format: "WAV"}
+	var blockAlign int
+	var dataSize, ds64DataSize int64
+	haveFmt, haveData := false, false
+
+	for {
+		h, err := readChunkHeader(r, binary.LittleEndian)
+		if err != nil {
+			break
+		}
+
+		switch h.ID {
+		case "ds64":
+			// RF64 keeps the real sizes of files over 4 GB here
+			buf, err := readChunk(r, h.Size)
+			if err != nil {
+				return nil, err
+			}
+			if len(buf) >= 16 {
+				ds64DataSize = int64(binary.LittleEndian.Uint64(buf[8:]))
+			}
+		case "fmt ":
+			buf, err := readChunk(r, h.Size)
+			if err != nil {
+				return nil, err
+			}
+			if len(buf) < 16 {
+				return nil, fmt.Errorf("fmt chunk too short")
+			}
+			tag := binary.LittleEndian.Uint16(buf)
+			info.Channels = int(binary.LittleEndian.Uint16(buf[2:]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(buf[4:]))
+			blockAlign = int(binary.LittleEndian.Uint16(buf[12:]))
+			info.BitDepth = int(binary.LittleEndian.Uint16(buf[14:]))
+			// WAVE_FORMAT_EXTENSIBLE stores the real format in its sub-format GUID
+			if tag == 0xFFFE && len(buf) >= 26 {
+				tag = binary.LittleEndian.Uint16(buf[24:])
+			}
+			info.Float = tag == 3
+			haveFmt = true
+		case "data":
+			dataSize = h.Size
+			if h.Size == 0xFFFFFFFF && ds64DataSize > 0 {
+				dataSize = ds64DataSize
+			}
+			haveData = true
+			if info.dataOffset, err = r.Seek(0, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			if err := skipChunk(r, dataSize); err != nil {
+				return nil, err
+			}
+		case "bext":
+			buf, err := readChunk(r, h.Size)
+			if err != nil {
+				return nil, err
+			}
+			info.BWF = parseBext(buf)
+		case "iXML":
+			buf, err := readChunk(r, h.Size)
+			if err != nil {
+				return nil, err
+			}
+			var ixml IXML
+			if xml.Unmarshal(bytes.TrimRight(buf, "\x00"), &ixml) == nil {
+				info.IXML = &ixml
+			}
+		default:
+			if err := skipChunk(r, h.Size); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !haveFmt || !haveData {
+		return nil, fmt.Errorf("WAV file has no fmt or data chunk")
+	}
+	if blockAlign > 0 {
+		info.Frames = dataSize / int64(blockAlign)
+	}
+	info.dataSize, info.blockAlign = dataSize, blockAlign
+	return info, nil
+}
+
+// readChunk reads a chunk body and its pad byte
+func readChunk(r io.ReadSeeker, size int64) ([]byte, error) {
+	if size > 1<<20 {
+		return nil, fmt.Errorf("chunk of %d bytes is too large", size)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	if size%2 == 1 {
+		if _, err := r.Seek(1, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// parseBext reads the fixed-size fields of a Broadcast Wave bext chunk
+func parseBext(buf []byte) *BWF {
+	if len(buf) < 346 {
+		return nil
+	}
+	text := func(b []byte) string {
+		return strings.TrimSpace(string(bytes.TrimRight(b, "\x00")))
+	}
+	return &BWF{
+		Description:     text(buf[0:256]),
+		Originator:      text(buf[256:288]),
+		OriginatorRef:   text(buf[288:320]),
+		OriginationDate: text(buf[320:330]),
+		OriginationTime: text(buf[330:338]),
+		TimeReference:   binary.LittleEndian.Uint64(buf[338:346]),
+	}
+}
+
+// readAIFF reads the COMM chunk of an AIFF or AIFF-C file
+func readAIFF(r io.ReadSeeker, compressed bool) (*Info, error) {
+	for {
+		h, err := readChunkHeader(r, binary.BigEndian)
+		if err != nil {
+			return nil, fmt.Errorf("AIFF file has no COMM chunk")
+		}
+		if h.ID != "COMM" {
+			if err := skipChunk(r, h.Size); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		buf, err := readChunk(r, h.Size)
+		if err != nil {
+			return nil, err
+		}
+		if len(buf) < 18 {
+			return nil, fmt.Errorf("COMM chunk too short")
+		}
+
+		info := &Info{
+			Format:     "AIFF",
+			Channels:   int(binary.BigEndian.Uint16(buf)),
+			Frames:     int64(binary.BigEndian.Uint32(buf[2:])),
+			BitDepth:   int(binary.BigEndian.Uint16(buf[6:])),
+			SampleRate: int(math.Round(extendedFloat(buf[8:18]))),
+		}
+		if compressed && len(buf) >= 22 {
+			switch strings.ToLower(string(buf[18:22])) {
+			case "fl32", "fl64":
+				info.Float = true
+			}
+		}
+		return info, nil
+	}
+}
+
+// extendedFloat decodes the 80-bit IEEE 754 extended precision number AIFF
+// uses for the sample rate
+func extendedFloat(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b)&0x7FFF) - 16383
+	mantissa := binary.BigEndian.Uint64(b[2:])
+	value := float64(mantissa) * math.Pow(2, float64(exponent-63))
+	if b[0]&0x80 != 0 {
+		value = -value
+	}
+	return value
+}
+
+// readFLAC reads the STREAMINFO metadata block of a FLAC file
+func readFLAC(r io.Reader) (*Info, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0]&0x7F != 0 {
+		return nil, fmt.Errorf("FLAC file does not start with STREAMINFO")
+	}
+
+	var buf [34]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+
+	// Sample rate (20 bits), channels - 1 (3 bits), bits per sample - 1
+	// (5 bits) and total samples (36 bits) follow the block and frame sizes
+	bits := binary.BigEndian.Uint64(buf[10:18])
+	return &Info{
+		Format:     "FLAC",
+		SampleRate: int(bits >> 44),
+		Channels:   int(bits>>41&0x7) + 1,
+		BitDepth:   int(bits>>36&0x1F) + 1,
+		Frames:     int64(bits & 0xFFFFFFFFF),
+	}, nil
+}
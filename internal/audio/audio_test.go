@@ -0,0 +1,446 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeWAV writes a 32-bit float WAV file of the given length whose samples
+// come from sample(frame, channel)
+func writeWAV(t *testing.T, rate, channels int, seconds float64, sample func(i, c int) float64) string {
+	t.Helper()
+
+	frames := int(seconds * float64(rate))
+	var data bytes.Buffer
+	for i := 0; i < frames; i++ {
+		for c := 0; c < channels; c++ {
+			binary.Write(&data, binary.LittleEndian, float32(sample(i, c)))
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+data.Len()))
+	buf.WriteString("WAVEfmt ")
+	for _, v := range []interface{}{
+		uint32(16), uint16(3), uint16(channels), uint32(rate),
+		uint32(rate * channels * 4), uint16(channels * 4), uint16(32),
+	} {
+		binary.Write(&buf, binary.LittleEndian, v)
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(data.Len()))
+	buf.Write(data.Bytes())
+
+	path := filepath.Join(t.TempDir(), "test.wav")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sine returns a sample function for a sine of the given frequency and level
+// in dBFS on the listed channels, silence on the others
+func sine(rate int, freq, level float64, channels ...int) func(i, c int) float64 {
+	amplitude := math.Pow(10, level/20)
+	return func(i, c int) float64 {
+		for _, on := range channels {
+			if on == c {
+				return amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate))
+			}
+		}
+		return 0
+	}
+}
+
+func analyze(t *testing.T, path string) *Loudness {
+	t.Helper()
+	l, err := Analyze(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestIntegratedLoudness(t *testing.T) {
+	// A mono 1 kHz sine at -20 dBFS measures -23 LUFS: -3 dB for its mean
+	// square, +0.691 dB of K-weighting gain at 1 kHz, -0.691 dB offset
+	l := analyze(t, writeWAV(t, 48000, 1, 20, sine(48000, 1000, -20, 0)))
+	if math.Abs(l.Integrated-(-23)) > 0.1 {
+		t.Errorf("integrated = %.2f LUFS, want -23.0 ±0.1", l.Integrated)
+	}
+	if math.Abs(l.Duration-20) > 1e-9 {
+		t.Errorf("duration = %v, want 20", l.Duration)
+	}
+	if l.Range > 0.1 {
+		t.Errorf("range = %.2f LU, want 0 for a steady tone", l.Range)
+	}
+}
+
+func TestChannelWeighting(t *testing.T) {
+	const rate = 48000
+	tests := []struct {
+		name     string
+		channels int
+		on       []int
+		want     float64
+	}{
+		// EBU Tech 3341 case 1: stereo, both channels at -23 dBFS
+		{"stereo", 2, []int{0, 1}, -23},
+		{"left only", 2, []int{0}, -23 - 10*math.Log10(2)},
+		{"5.1 centre", 6, []int{2}, -23 - 10*math.Log10(2)},
+		{"5.1 left surround", 6, []int{4}, -23 - 10*math.Log10(2) + 10*math.Log10(1.41)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := analyze(t, writeWAV(t, rate, tt.channels, 10, sine(rate, 1000, -23, tt.on...)))
+			if math.Abs(l.Integrated-tt.want) > 0.1 {
+				t.Errorf("integrated = %.2f LUFS, want %.2f", l.Integrated, tt.want)
+			}
+		})
+	}
+
+	// The LFE channel of a 5.1 file is not measured
+	l := analyze(t, writeWAV(t, rate, 6, 5, sine(rate, 1000, -10, 3)))
+	if l.Integrated != absoluteGate {
+		t.Errorf("LFE only: integrated = %.2f LUFS, want %v", l.Integrated, absoluteGate)
+	}
+}
+
+func TestLoudnessRange(t *testing.T) {
+	// EBU Tech 3342 case 1: 20 s at -20 dBFS followed by 20 s at -30 dBFS
+	path := writeWAV(t, 48000, 2, 40, func(i, c int) float64 {
+		level := -20.0
+		if i >= 20*48000 {
+			level = -30
+		}
+		return math.Pow(10, level/20) * math.Sin(2*math.Pi*1000*float64(i)/48000)
+	})
+	if l := analyze(t, path); math.Abs(l.Range-10) > 1 {
+		t.Errorf("range = %.2f LU, want 10 ±1", l.Range)
+	}
+}
+
+func TestTruePeak(t *testing.T) {
+	// A full-scale sine at a quarter of the sample rate, shifted by 45°, has
+	// every sample at ±0.707 (-3 dBFS) while the waveform peaks at 0 dBFS
+	const rate = 48000
+	path := writeWAV(t, rate, 1, 2, func(i, c int) float64 {
+		return math.Sin(2*math.Pi*float64(i)/4 + math.Pi/4)
+	})
+	l := analyze(t, path)
+	if l.TruePeak < -0.4 || l.TruePeak > 0.2 {
+		t.Errorf("true peak = %.2f dBTP, want 0 (+0.2/-0.4)", l.TruePeak)
+	}
+}
+
+func TestSilence(t *testing.T) {
+	l := analyze(t, writeWAV(t, 48000, 2, 3, func(i, c int) float64 { return 0 }))
+	for name, v := range map[string]float64{"integrated": l.Integrated, "true peak": l.TruePeak, "range": l.Range} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Errorf("%s = %v for silence", name, v)
+		}
+	}
+	if l.Integrated != absoluteGate {
+		t.Errorf("integrated = %v, want %v", l.Integrated, absoluteGate)
+	}
+	if l.TruePeak != minPeak {
+		t.Errorf("true peak = %v, want %v", l.TruePeak, minPeak)
+	}
+	if l.Range != 0 {
+		t.Errorf("range = %v, want 0", l.Range)
+	}
+}
+
+func TestDecodeFLAC(t *testing.T) {
+	// mixed.flac is 16-bit stereo whose frames use, in turn: FIXED order 2
+	// (independent channels), LPC (mid/side, 5-bit Rice parameters),
+	// VERBATIM (left/side), FIXED order 4 with a wasted bit and an escaped
+	// residual partition (side/right), CONSTANT, FIXED order 1 with an 8-bit
+	// block size (mid/side), FIXED order 0 and FIXED order 3 (left/side).
+	// mixed.pcm holds the same samples as interleaved 16-bit PCM.
+	pcm, err := os.ReadFile("testdata/mixed.pcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [2][]float64
+	info, err := Decode("testdata/mixed.flac", func(block [][]float64) error {
+		for c := range block {
+			got[c] = append(got[c], block[c]...)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames := len(pcm) / 4
+	if info.Frames != int64(frames) || len(got[0]) != frames || len(got[1]) != frames {
+		t.Fatalf("decoded %d/%d frames (header %d), want %d", len(got[0]), len(got[1]), info.Frames, frames)
+	}
+	for i := 0; i < frames; i++ {
+		for c := 0; c < 2; c++ {
+			want := int16(binary.LittleEndian.Uint16(pcm[i*4+c*2:]))
+			if s := int(math.Round(got[c][i] * 32768)); s != int(want) {
+				t.Fatalf("frame %d channel %d = %d, want %d", i, c, s, want)
+			}
+		}
+	}
+}
+
+func TestDecodeWAVFormats(t *testing.T) {
+	tests := []struct {
+		name  string
+		bytes []byte
+		want  float64
+	}{
+		{"8-bit", []byte{0xC0}, 0.5},
+		{"16-bit", []byte{0x00, 0xC0}, -0.5},
+		{"24-bit", []byte{0x00, 0x00, 0x40}, 0.5},
+		{"32-bit", []byte{0x00, 0x00, 0x00, 0x80}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width := len(tt.bytes)
+			if got := wavSample(tt.bytes, false, math.Ldexp(1, 8*width-1)); got != tt.want {
+				t.Errorf("wavSample = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// riffChunk encodes a chunk with its size in the given byte order and a pad
+// byte after odd-sized bodies
+func riffChunk(order binary.ByteOrder, id string, body []byte) []byte {
+	out := make([]byte, 8, 8+len(body)+1)
+	copy(out, id)
+	order.PutUint32(out[4:], uint32(len(body)))
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// riffFile encodes a RIFF, RF64 or FORM file of the given form type
+func riffFile(order binary.ByteOrder, id, form string, chunks ...[]byte) []byte {
+	body := []byte(form)
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return riffChunk(order, id, body)
+}
+
+// fmtChunk encodes a WAV fmt chunk. Tag 0xFFFE writes WAVE_FORMAT_EXTENSIBLE
+// with the given sub-format.
+func fmtChunk(tag, subFormat uint16, channels, rate, bits int) []byte {
+	le := binary.LittleEndian
+	body := make([]byte, 16, 40)
+	le.PutUint16(body, tag)
+	le.PutUint16(body[2:], uint16(channels))
+	le.PutUint32(body[4:], uint32(rate))
+	le.PutUint32(body[8:], uint32(rate*channels*bits/8))
+	le.PutUint16(body[12:], uint16(channels*bits/8))
+	le.PutUint16(body[14:], uint16(bits))
+	if tag == 0xFFFE {
+		ext := make([]byte, 24)
+		le.PutUint16(ext, 22)
+		le.PutUint16(ext[2:], uint16(bits))
+		le.PutUint32(ext[4:], 0x3F)
+		// KSDATAFORMAT_SUBTYPE GUID: the format tag, then a fixed suffix
+		le.PutUint16(ext[8:], subFormat)
+		copy(ext[12:], "\x00\x00\x10\x00\x80\x00\x00\xAA\x00\x38\x9B\x71")
+		body = append(body, ext...)
+	}
+	return riffChunk(le, "fmt ", body)
+}
+
+// extended encodes a number as an 80-bit IEEE 754 extended precision float
+func extended(v float64) []byte {
+	frac, exp := math.Frexp(v)
+	out := make([]byte, 10)
+	binary.BigEndian.PutUint16(out, uint16(exp-1+16383))
+	binary.BigEndian.PutUint64(out[2:], uint64(math.Ldexp(frac, 64)))
+	return out
+}
+
+// commChunk encodes an AIFF COMM chunk, with a compression type for AIFF-C
+func commChunk(channels, frames, bits int, rate float64, compression string) []byte {
+	body := make([]byte, 8)
+	binary.BigEndian.PutUint16(body, uint16(channels))
+	binary.BigEndian.PutUint32(body[2:], uint32(frames))
+	binary.BigEndian.PutUint16(body[6:], uint16(bits))
+	body = append(body, extended(rate)...)
+	if compression != "" {
+		body = append(body, compression...)
+		body = append(body, 0, 0)
+	}
+	return riffChunk(binary.BigEndian, "COMM", body)
+}
+
+func TestReadHeaders(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+
+	bext := make([]byte, 602)
+	copy(bext, "Scene 4 take 2")
+	copy(bext[256:], "Sound Devices")
+	copy(bext[320:], "2026-10-18")
+	copy(bext[330:], "12:30:00")
+	le.PutUint64(bext[338:], 48000*3600)
+
+	ds64 := make([]byte, 28)
+	le.PutUint64(ds64[8:], 9600)
+	rf64Data := append([]byte("data\xFF\xFF\xFF\xFF"), make([]byte, 9600)...)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		format   string
+		rate     int
+		channels int
+		depth    int
+		float    bool
+		frames   int64
+		duration float64
+	}{
+		{"WAV PCM", riffFile(le, "RIFF", "WAVE",
+			riffChunk(le, "JUNK", make([]byte, 27)),
+			fmtChunk(1, 0, 2, 44100, 16),
+			riffChunk(le, "data", make([]byte, 44100*4/2)),
+		), "WAV", 44100, 2, 16, false, 22050, 0.5},
+		{"WAV float", riffFile(le, "RIFF", "WAVE",
+			fmtChunk(3, 0, 1, 96000, 32),
+			riffChunk(le, "data", make([]byte, 96000*4/4)),
+		), "WAV", 96000, 1, 32, true, 24000, 0.25},
+		{"WAV extensible PCM", riffFile(le, "RIFF", "WAVE",
+			fmtChunk(0xFFFE, 1, 6, 48000, 24),
+			riffChunk(le, "data", make([]byte, 4800*18)),
+		), "WAV", 48000, 6, 24, false, 4800, 0.1},
+		{"WAV extensible float", riffFile(le, "RIFF", "WAVE",
+			fmtChunk(0xFFFE, 3, 2, 48000, 32),
+			riffChunk(le, "data", make([]byte, 48000*8)),
+		), "WAV", 48000, 2, 32, true, 48000, 1},
+		{"WAV with bext and iXML after data", riffFile(le, "RIFF", "WAVE",
+			fmtChunk(1, 0, 1, 48000, 24),
+			riffChunk(le, "data", make([]byte, 3*1001)),
+			riffChunk(le, "bext", bext),
+			riffChunk(le, "iXML", []byte("<BWFXML><PROJECT>Night Drive</PROJECT><SCENE>4</SCENE><TAKE>2</TAKE></BWFXML>\x00")),
+		), "WAV", 48000, 1, 24, false, 1001, 1001.0 / 48000},
+		{"RF64", append(append([]byte("RF64\xFF\xFF\xFF\xFFWAVE"),
+			append(riffChunk(le, "ds64", ds64), fmtChunk(1, 0, 1, 48000, 16)...)...),
+			rf64Data...,
+		), "WAV", 48000, 1, 16, false, 4800, 0.1},
+		{"AIFF", riffFile(be, "FORM", "AIFF",
+			commChunk(2, 88200, 16, 44100, ""),
+			riffChunk(be, "SSND", make([]byte, 8)),
+		), "AIFF", 44100, 2, 16, false, 88200, 2},
+		{"AIFF after other chunks", riffFile(be, "FORM", "AIFF",
+			riffChunk(be, "NAME", []byte("odd")),
+			commChunk(1, 96000, 24, 96000, ""),
+		), "AIFF", 96000, 1, 24, false, 96000, 1},
+		{"AIFF 22.05 kHz", riffFile(be, "FORM", "AIFF",
+			commChunk(1, 11025, 8, 22050, ""),
+		), "AIFF", 22050, 1, 8, false, 11025, 0.5},
+		{"AIFF-C float", riffFile(be, "FORM", "AIFC",
+			riffChunk(be, "FVER", make([]byte, 4)),
+			commChunk(2, 176400, 32, 176400, "fl32"),
+		), "AIFF", 176400, 2, 32, true, 176400, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Read(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Format != tt.format || info.SampleRate != tt.rate || info.Channels != tt.channels ||
+				info.BitDepth != tt.depth || info.Float != tt.float || info.Frames != tt.frames {
+				t.Errorf("info = %+v", info)
+			}
+			if math.Abs(info.Duration()-tt.duration) > 1e-9 {
+				t.Errorf("duration = %v, want %v", info.Duration(), tt.duration)
+			}
+		})
+	}
+}
+
+func TestReadBroadcastWave(t *testing.T) {
+	le := binary.LittleEndian
+	bext := make([]byte, 602)
+	copy(bext, "Scene 4 take 2")
+	copy(bext[256:], "Sound Devices")
+	copy(bext[320:], "2026-10-18")
+	copy(bext[330:], "12:30:00")
+	le.PutUint64(bext[338:], 48000*3600)
+
+	data := riffFile(le, "RIFF", "WAVE",
+		riffChunk(le, "bext", bext),
+		fmtChunk(1, 0, 1, 48000, 24),
+		riffChunk(le, "iXML", []byte("<BWFXML><PROJECT>Night Drive</PROJECT><SCENE>4</SCENE><TAKE>2</TAKE></BWFXML>\x00")),
+		riffChunk(le, "data", make([]byte, 3*48000)),
+	)
+	info, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := BWF{
+		Description:     "Scene 4 take 2",
+		Originator:      "Sound Devices",
+		OriginationDate: "2026-10-18",
+		OriginationTime: "12:30:00",
+		TimeReference:   48000 * 3600,
+	}
+	if info.BWF == nil || *info.BWF != want {
+		t.Errorf("bext = %+v, want %+v", info.BWF, want)
+	}
+	if info.IXML == nil || *info.IXML != (IXML{Project: "Night Drive", Scene: "4", Take: "2"}) {
+		t.Errorf("iXML = %+v", info.IXML)
+	}
+}
+
+func TestReadFLACHeader(t *testing.T) {
+	info, err := ReadFile("testdata/mixed.flac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := os.Stat("testdata/mixed.pcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != "FLAC" || info.Channels != 2 || info.BitDepth != 16 || info.Frames != pcm.Size()/4 {
+		t.Errorf("info = %+v", info)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	le := binary.LittleEndian
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrUnsupported},
+		{"Ogg", []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00"), ErrUnsupported},
+		{"RIFF that is not WAVE", riffFile(le, "RIFF", "AVI "), ErrUnsupported},
+		{"WAV without data", riffFile(le, "RIFF", "WAVE", fmtChunk(1, 0, 2, 44100, 16)), nil},
+		{"WAV without fmt", riffFile(le, "RIFF", "WAVE", riffChunk(le, "data", make([]byte, 4))), nil},
+		{"AIFF without COMM", riffFile(binary.BigEndian, "FORM", "AIFF", riffChunk(binary.BigEndian, "SSND", make([]byte, 8))), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(tt.data))
+			if err == nil {
+				t.Fatal("Read succeeded")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// decodeBlockFrames is the number of frames Decode passes per WAV block
+const decodeBlockFrames = 4096
+
+// Decode reads the samples of a WAV or FLAC file and passes them to fn in
+// blocks, one slice per channel with values between -1 and 1. The slices are
+// reused between calls.
+func Decode(path string, fn func(block [][]float64) error) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := Read(f)
+	if err != nil {
+		return nil, err
+	}
+	if info.Channels == 0 {
+		return nil, fmt.Errorf("file has no channels")
+	}
+
+	switch info.Format {
+	case "WAV":
+		return info, decodeWAV(f, info, fn)
+	case "FLAC":
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return info, decodeFLAC(bufio.NewReaderSize(f, 1<<16), info, fn)
+	}
+	return nil, fmt.Errorf("decoding %s files is not supported", info.Format)
+}
+
+// decodeWAV converts the integer or float samples of a WAV data chunk
+func decodeWAV(f io.ReadSeeker, info *Info, fn func([][]float64) error) error {
+	channels := info.Channels
+	width := info.blockAlign / channels
+	if width == 0 || info.blockAlign%channels != 0 {
+		return fmt.Errorf("unsupported WAV layout: %d channels in %d byte frames", channels, info.blockAlign)
+	}
+	if info.Float && width != 4 && width != 8 {
+		return fmt.Errorf("unsupported %d-bit float WAV", width*8)
+	}
+	if !info.Float && width > 4 {
+		return fmt.Errorf("unsupported %d-bit WAV", width*8)
+	}
+
+	if _, err := f.Seek(info.dataOffset, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(io.LimitReader(f, info.dataSize), 1<<16)
+
+	block := make([][]float64, channels)
+	for i := range block {
+		block[i] = make([]float64, decodeBlockFrames)
+	}
+	buf := make([]byte, decodeBlockFrames*info.blockAlign)
+	scale := math.Ldexp(1, 8*width-1)
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		frames := n / info.blockAlign
+		if frames > 0 {
+			for i := 0; i < frames; i++ {
+				frame := buf[i*info.blockAlign:]
+				for c := 0; c < channels; c++ {
+					block[c][i] = wavSample(frame[c*width:c*width+width], info.Float, scale)
+				}
+			}
+			out := make([][]float64, channels)
+			for c := range block {
+				out[c] = block[c][:frames]
+			}
+			if err := fn(out); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// wavSample converts one little-endian sample. 8-bit WAV samples are unsigned.
+func wavSample(b []byte, float bool, scale float64) float64 {
+	if float {
+		if len(b) == 4 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+
+	if len(b) == 1 {
+		return (float64(b[0]) - 128) / scale
+	}
+	var v int32
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | int32(b[i])
+	}
+	// Sign-extend from the sample width
+	shift := 32 - 8*uint(len(b))
+	v = v << shift >> shift
+	return float64(v) / scale
+}
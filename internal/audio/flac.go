@@ -0,0 +1,444 @@
+package audio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bitReader reads big-endian bit fields from a FLAC stream
+type bitReader struct {
+	r *bufio.Reader
+	x uint64 // bits read from r but not yet consumed, in the low n bits
+	n uint
+}
+
+// bits reads an unsigned field of up to 32 bits
+func (br *bitReader) bits(n uint) (uint64, error) {
+	for br.n < n {
+		b, err := br.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		br.x = br.x<<8 | uint64(b)
+		br.n += 8
+	}
+	br.n -= n
+	v := br.x >> br.n
+	br.x &= 1<<br.n - 1
+	return v, nil
+}
+
+// signed reads a two's complement field of up to 32 bits
+func (br *bitReader) signed(n uint) (int64, error) {
+	v, err := br.bits(n)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	shift := 64 - n
+	return int64(v<<shift) >> shift, nil
+}
+
+// unary counts the zero bits before the next one bit
+func (br *bitReader) unary() (uint64, error) {
+	var count uint64
+	for {
+		if br.n == 0 {
+			b, err := br.r.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			if b == 0 {
+				count += 8
+				continue
+			}
+			br.x, br.n = uint64(b), 8
+		}
+		br.n--
+		if br.x>>br.n&1 == 1 {
+			br.x &= 1<<br.n - 1
+			return count, nil
+		}
+		count++
+	}
+}
+
+// align drops the bits left in the current byte
+func (br *bitReader) align() {
+	br.n -= br.n % 8
+	br.x &= 1<<br.n - 1
+}
+
+// flacFrame holds the header fields of a FLAC frame needed to decode it
+type flacFrame struct {
+	BlockSize  int
+	Channels   int
+	Assignment int // 0-7 independent, 8 left/side, 9 side/right, 10 mid/side
+	BitDepth   int
+}
+
+// decodeFLAC decodes the frames of a FLAC stream positioned at its "fLaC" marker
+func decodeFLAC(r *bufio.Reader, info *Info, fn func([][]float64) error) error {
+	if _, err := r.Discard(4); err != nil {
+		return err
+	}
+
+	// Skip the metadata blocks
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		if _, err := r.Discard(size); err != nil {
+			return err
+		}
+		if header[0]&0x80 != 0 {
+			break
+		}
+	}
+
+	br := &bitReader{r: r}
+	samples := make([][]int64, info.Channels)
+	block := make([][]float64, info.Channels)
+	var decoded int64
+
+	for info.Frames == 0 || decoded < info.Frames {
+		frame, err := readFLACFrameHeader(br, info)
+		if err != nil {
+			// Files without a known length simply end
+			if info.Frames == 0 && errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if frame.Channels != info.Channels {
+			return fmt.Errorf("FLAC frame has %d channels, expected %d", frame.Channels, info.Channels)
+		}
+
+		for c := 0; c < frame.Channels; c++ {
+			depth := frame.BitDepth
+			// The side channel needs one extra bit
+			if (frame.Assignment == 8 && c == 1) || (frame.Assignment == 9 && c == 0) || (frame.Assignment == 10 && c == 1) {
+				depth++
+			}
+			if cap(samples[c]) < frame.BlockSize {
+				samples[c] = make([]int64, frame.BlockSize)
+			}
+			samples[c] = samples[c][:frame.BlockSize]
+			if err := readFLACSubframe(br, samples[c], uint(depth)); err != nil {
+				return err
+			}
+		}
+		decorrelate(samples, frame.Assignment)
+
+		// Padding to the byte boundary, then the frame CRC-16
+		br.align()
+		if _, err := br.bits(16); err != nil {
+			return err
+		}
+
+		scale := float64(int64(1) << (frame.BitDepth - 1))
+		for c := range samples {
+			if cap(block[c]) < frame.BlockSize {
+				block[c] = make([]float64, frame.BlockSize)
+			}
+			block[c] = block[c][:frame.BlockSize]
+			for i, s := range samples[c] {
+				block[c][i] = float64(s) / scale
+			}
+		}
+		if err := fn(block); err != nil {
+			return err
+		}
+		decoded += int64(frame.BlockSize)
+	}
+	return nil
+}
+
+// readFLACFrameHeader reads a frame header up to and including its CRC-8
+func readFLACFrameHeader(br *bitReader, info *Info) (flacFrame, error) {
+	sync, err := br.bits(16)
+	if err != nil {
+		return flacFrame{}, err
+	}
+	if sync&0xFFFE != 0xFFF8 {
+		return flacFrame{}, fmt.Errorf("lost FLAC frame sync")
+	}
+
+	codes, err := br.bits(16)
+	if err != nil {
+		return flacFrame{}, err
+	}
+	sizeCode := int(codes >> 12)
+	rateCode := int(codes >> 8 & 0xF)
+	assignment := int(codes >> 4 & 0xF)
+	depthCode := int(codes >> 1 & 0x7)
+
+	// The frame or sample number is UTF-8 coded; only its length matters here
+	first, err := br.bits(8)
+	if err != nil {
+		return flacFrame{}, err
+	}
+	for mask := uint64(0x80); first&mask != 0 && mask > 1; mask >>= 1 {
+		if mask != 0x80 {
+			if _, err := br.bits(8); err != nil {
+				return flacFrame{}, err
+			}
+		}
+	}
+
+	frame := flacFrame{Assignment: assignment}
+	switch {
+	case sizeCode == 1:
+		frame.BlockSize = 192
+	case sizeCode >= 2 && sizeCode <= 5:
+		frame.BlockSize = 576 << (sizeCode - 2)
+	case sizeCode == 6 || sizeCode == 7:
+		v, err := br.bits(uint(8 * (sizeCode - 5)))
+		if err != nil {
+			return flacFrame{}, err
+		}
+		frame.BlockSize = int(v) + 1
+	case sizeCode >= 8:
+		frame.BlockSize = 256 << (sizeCode - 8)
+	default:
+		return flacFrame{}, fmt.Errorf("invalid FLAC block size")
+	}
+
+	switch rateCode {
+	case 12:
+		_, err = br.bits(8)
+	case 13, 14:
+		_, err = br.bits(16)
+	case 15:
+		err = fmt.Errorf("invalid FLAC sample rate")
+	}
+	if err != nil {
+		return flacFrame{}, err
+	}
+
+	switch {
+	case assignment < 8:
+		frame.Channels = assignment + 1
+	case assignment <= 10:
+		frame.Channels = 2
+	default:
+		return flacFrame{}, fmt.Errorf("invalid FLAC channel assignment")
+	}
+
+	frame.BitDepth = [8]int{info.BitDepth, 8, 12, 0, 16, 20, 24, 32}[depthCode]
+	if frame.BitDepth == 0 {
+		return flacFrame{}, fmt.Errorf("invalid FLAC sample size")
+	}
+
+	// CRC-8 of the header
+	if _, err := br.bits(8); err != nil {
+		return flacFrame{}, err
+	}
+	return frame, nil
+}
+
+// fixedCoefficients are the predictors of FLAC's FIXED subframes by order
+var fixedCoefficients = [5][]int64{
+	{},
+	{1},
+	{2, -1},
+	{3, -3, 1},
+	{4, -6, 4, -1},
+}
+
+// readFLACSubframe decodes one channel of a frame into samples
+func readFLACSubframe(br *bitReader, samples []int64, depth uint) error {
+	header, err := br.bits(8)
+	if err != nil {
+		return err
+	}
+	kind := int(header >> 1 & 0x3F)
+
+	// Wasted bits: samples were shifted right before encoding
+	var wasted uint
+	if header&1 == 1 {
+		k, err := br.unary()
+		if err != nil {
+			return err
+		}
+		wasted = uint(k) + 1
+		depth -= wasted
+	}
+
+	switch {
+	case kind == 0:
+		v, err := br.signed(depth)
+		if err != nil {
+			return err
+		}
+		for i := range samples {
+			samples[i] = v
+		}
+	case kind == 1:
+		for i := range samples {
+			if samples[i], err = br.signed(depth); err != nil {
+				return err
+			}
+		}
+	case kind >= 8 && kind <= 12:
+		order := kind - 8
+		if err := readWarmup(br, samples, order, depth); err != nil {
+			return err
+		}
+		if err := readResidual(br, samples, order); err != nil {
+			return err
+		}
+		predict(samples, fixedCoefficients[order], 0)
+	case kind >= 32:
+		order := kind - 31
+		if err := readWarmup(br, samples, order, depth); err != nil {
+			return err
+		}
+		precision, err := br.bits(4)
+		if err != nil {
+			return err
+		}
+		if precision == 15 {
+			return fmt.Errorf("invalid FLAC LPC precision")
+		}
+		shift, err := br.signed(5)
+		if err != nil {
+			return err
+		}
+		if shift < 0 {
+			return fmt.Errorf("negative FLAC LPC shift")
+		}
+		coeffs := make([]int64, order)
+		for i := range coeffs {
+			if coeffs[i], err = br.signed(uint(precision) + 1); err != nil {
+				return err
+			}
+		}
+		if err := readResidual(br, samples, order); err != nil {
+			return err
+		}
+		predict(samples, coeffs, uint(shift))
+	default:
+		return fmt.Errorf("reserved FLAC subframe type %d", kind)
+	}
+
+	if wasted > 0 {
+		for i := range samples {
+			samples[i] <<= wasted
+		}
+	}
+	return nil
+}
+
+// readWarmup reads the unencoded first samples of a predicted subframe
+func readWarmup(br *bitReader, samples []int64, order int, depth uint) error {
+	if order > len(samples) {
+		return fmt.Errorf("FLAC predictor order %d exceeds block size", order)
+	}
+	for i := 0; i < order; i++ {
+		v, err := br.signed(depth)
+		if err != nil {
+			return err
+		}
+		samples[i] = v
+	}
+	return nil
+}
+
+// readResidual reads the Rice coded prediction errors that follow the warm-up
+// samples
+func readResidual(br *bitReader, samples []int64, order int) error {
+	method, err := br.bits(2)
+	if err != nil {
+		return err
+	}
+	if method > 1 {
+		return fmt.Errorf("reserved FLAC residual coding method")
+	}
+	paramBits, escape := uint(4), uint64(15)
+	if method == 1 {
+		paramBits, escape = 5, 31
+	}
+
+	partitionOrder, err := br.bits(4)
+	if err != nil {
+		return err
+	}
+	partitions := 1 << partitionOrder
+	partitionSize := len(samples) >> partitionOrder
+	if partitionSize<<partitionOrder != len(samples) || partitionSize < order {
+		return fmt.Errorf("invalid FLAC residual partitioning")
+	}
+
+	i := order
+	for p := 0; p < partitions; p++ {
+		end := (p + 1) * partitionSize
+		param, err := br.bits(paramBits)
+		if err != nil {
+			return err
+		}
+
+		if param == escape {
+			raw, err := br.bits(5)
+			if err != nil {
+				return err
+			}
+			for ; i < end; i++ {
+				if samples[i], err = br.signed(uint(raw)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for ; i < end; i++ {
+			q, err := br.unary()
+			if err != nil {
+				return err
+			}
+			r, err := br.bits(uint(param))
+			if err != nil {
+				return err
+			}
+			u := q<<param | r
+			samples[i] = int64(u>>1) ^ -int64(u&1)
+		}
+	}
+	return nil
+}
+
+// predict turns the residuals after the warm-up samples into samples using a
+// linear predictor
+func predict(samples []int64, coeffs []int64, shift uint) {
+	order := len(coeffs)
+	for i := order; i < len(samples); i++ {
+		var sum int64
+		for j, c := range coeffs {
+			sum += c * samples[i-1-j]
+		}
+		samples[i] += sum >> shift
+	}
+}
+
+// decorrelate restores left and right from FLAC's stereo channel assignments
+func decorrelate(samples [][]int64, assignment int) {
+	switch assignment {
+	case 8: // left, side
+		for i, side := range samples[1] {
+			samples[1][i] = samples[0][i] - side
+		}
+	case 9: // side, right
+		for i, side := range samples[0] {
+			samples[0][i] = samples[1][i] + side
+		}
+	case 10: // mid, side
+		for i, side := range samples[1] {
+			mid := samples[0][i]<<1 | side&1
+			samples[0][i] = (mid + side) >> 1
+			samples[1][i] = (mid - side) >> 1
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package audio
+
+import (
+	"math"
+	"sort"
+)
+
+// Loudness is an ITU-R BS.1770 / EBU R 128 measurement of a file
+type Loudness struct {
+	Integrated float64 // integrated loudness in LUFS
+	TruePeak   float64 // maximum true peak in dBTP
+	Range      float64 // loudness range in LU
+	Duration   float64 // length in seconds
+}
+
+// Gates and levels of BS.1770 and EBU Tech 3342. Silence measures at the
+// absolute gate, and peaks are reported no lower than minPeak.
+const (
+	absoluteGate     = -70.0
+	integratedGate   = -10.0
+	rangeGate        = -20.0
+	minPeak          = -120.0
+	segmentsPerBlock = 4  // 400 ms momentary blocks
+	segmentsPerRange = 30 // 3 s short-term windows
+)
+
+// truePeakFilter is the 48-tap, 4-phase interpolation filter BS.1770 Annex 2
+// uses to estimate peaks between samples
+var truePeakFilter = [4][12]float64{
+	{0.0017089843750, 0.0109863281250, -0.0196533203125, 0.0332031250000, -0.0594482421875, 0.1373291015625, 0.9721679687500, -0.1022949218750, 0.0476074218750, -0.0266113281250, 0.0148925781250, -0.0083007812500},
+	{-0.0291748046875, 0.0292968750000, -0.0517578125000, 0.0891113281250, -0.1665039062500, 0.4650878906250, 0.7797851562500, -0.2003173828125, 0.1015625000000, -0.0582275390625, 0.0330810546875, -0.0189208984375},
+	{-0.0189208984375, 0.0330810546875, -0.0582275390625, 0.1015625000000, -0.2003173828125, 0.7797851562500, 0.4650878906250, -0.1665039062500, 0.0891113281250, -0.0517578125000, 0.0292968750000, -0.0291748046875},
+	{-0.0083007812500, 0.0148925781250, -0.0266113281250, 0.0476074218750, -0.1022949218750, 0.9721679687500, 0.1373291015625, -0.0594482421875, 0.0332031250000, -0.0196533203125, 0.0109863281250, 0.0017089843750},
+}
+
+// Analyze decodes a WAV or FLAC file and measures its integrated loudness,
+// true peak and loudness range
+func Analyze(path string) (*Loudness, error) {
+	info, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMeter(info.SampleRate, info.Channels)
+	if _, err := Decode(path, m.add); err != nil {
+		return nil, err
+	}
+	return m.result(), nil
+}
+
+// biquad is a second order IIR filter section
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+// process filters one sample
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the BS.1770 pre-filter (a high shelf modelling the head)
+// and RLB high-pass filter for a sample rate. The coefficients are derived from
+// the analog prototypes so rates other than 48 kHz work too.
+func kWeighting(rate int) (biquad, biquad) {
+	fs := float64(rate)
+
+	k := math.Tan(math.Pi * 1681.974450955533 / fs)
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * 38.13547087602444 / fs)
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highpass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highpass
+}
+
+// channelWeights returns the BS.1770 weight of each channel: surround channels
+// of 5.0 and 5.1 files count 1.41 and the LFE channel is left out
+func channelWeights(channels int) []float64 {
+	weights := make([]float64, channels)
+	for i := range weights {
+		weights[i] = 1
+	}
+	switch channels {
+	case 5: // L R C Ls Rs
+		weights[3], weights[4] = 1.41, 1.41
+	case 6: // L R C LFE Ls Rs
+		weights[3], weights[4], weights[5] = 0, 1.41, 1.41
+	}
+	return weights
+}
+
+// meter accumulates the mean square of K-weighted audio in 100 ms segments,
+// from which the gated block and window measurements are computed at the end,
+// and tracks the true peak
+type meter struct {
+	rate       int
+	weights    []float64
+	shelves    []biquad
+	highpasses []biquad
+	history    [][12]float64
+	oversample bool
+
+	segmentLength int
+	segmentFill   int
+	segmentEnergy float64
+	segments      []float64
+
+	peak   float64
+	frames int64
+}
+
+// newMeter prepares a meter for audio with the given rate and channel count.
+// True peak uses 4x oversampling below 96 kHz; higher rates use the sample peak.
+func newMeter(rate, channels int) *meter {
+	m := &meter{
+		rate:          rate,
+		weights:       channelWeights(channels),
+		shelves:       make([]biquad, channels),
+		highpasses:    make([]biquad, channels),
+		history:       make([][12]float64, channels),
+		oversample:    rate < 96000,
+		segmentLength: int(math.Round(float64(rate) / 10)),
+	}
+	for c := range m.shelves {
+		m.shelves[c], m.highpasses[c] = kWeighting(rate)
+	}
+	return m
+}
+
+// add feeds a block of samples, one slice per channel
+func (m *meter) add(block [][]float64) error {
+	frames := len(block[0])
+	for i := 0; i < frames; i++ {
+		var energy float64
+		for c, samples := range block {
+			x := samples[i]
+			m.measurePeak(c, x)
+			if m.weights[c] == 0 {
+				continue
+			}
+			z := m.highpasses[c].process(m.shelves[c].process(x))
+			energy += m.weights[c] * z * z
+		}
+
+		m.segmentEnergy += energy
+		m.segmentFill++
+		if m.segmentFill == m.segmentLength {
+			m.segments = append(m.segments, m.segmentEnergy/float64(m.segmentLength))
+			m.segmentEnergy, m.segmentFill = 0, 0
+		}
+	}
+	m.frames += int64(frames)
+	return nil
+}
+
+// measurePeak updates the peak with a sample and, when oversampling, the
+// three interpolated values between it and the previous sample
+func (m *meter) measurePeak(channel int, x float64) {
+	if a := math.Abs(x); a > m.peak {
+		m.peak = a
+	}
+	if !m.oversample {
+		return
+	}
+
+	h := &m.history[channel]
+	copy(h[1:], h[:11])
+	h[0] = x
+	for _, phase := range truePeakFilter {
+		var y float64
+		for k, coeff := range phase {
+			y += coeff * h[k]
+		}
+		if a := math.Abs(y); a > m.peak {
+			m.peak = a
+		}
+	}
+}
+
+// result computes the measurements from the collected segments
+func (m *meter) result() *Loudness {
+	l := &Loudness{
+		Integrated: absoluteGate,
+		TruePeak:   minPeak,
+	}
+	if m.rate > 0 {
+		l.Duration = float64(m.frames) / float64(m.rate)
+	}
+	if m.peak > 0 {
+		l.TruePeak = math.Max(20*math.Log10(m.peak), minPeak)
+	}
+
+	blocks := m.windows(segmentsPerBlock)
+	if gated := gate(blocks, integratedGate); len(gated) > 0 {
+		l.Integrated = loudness(mean(gated))
+	}
+
+	// Loudness range is the spread between the 10th and 95th percentile of
+	// the gated short-term loudness
+	gated := gate(m.windows(segmentsPerRange), rangeGate)
+	if len(gated) > 1 {
+		levels := make([]float64, len(gated))
+		for i, power := range gated {
+			levels[i] = loudness(power)
+		}
+		sort.Float64s(levels)
+		low := levels[int(math.Round(float64(len(levels)-1)*0.10))]
+		high := levels[int(math.Round(float64(len(levels)-1)*0.95))]
+		l.Range = high - low
+	}
+	return l
+}
+
+// windows returns the mean square of every window of n segments, advancing
+// one segment (100 ms) at a time
+func (m *meter) windows(n int) []float64 {
+	if len(m.segments) < n {
+		return nil
+	}
+	windows := make([]float64, 0, len(m.segments)-n+1)
+	var sum float64
+	for i, s := range m.segments {
+		sum += s
+		if i >= n {
+			sum -= m.segments[i-n]
+		}
+		if i >= n-1 {
+			windows = append(windows, sum/float64(n))
+		}
+	}
+	return windows
+}
+
+// gate drops the windows below the absolute gate, then those more than
+// relative LU below the loudness of the rest
+func gate(powers []float64, relative float64) []float64 {
+	var loud []float64
+	for _, p := range powers {
+		if loudness(p) > absoluteGate {
+			loud = append(loud, p)
+		}
+	}
+	if len(loud) == 0 {
+		return nil
+	}
+
+	threshold := loudness(mean(loud)) + relative
+	var gated []float64
+	for _, p := range loud {
+		if loudness(p) > threshold {
+			gated = append(gated, p)
+		}
+	}
+	return gated
+}
+
+// loudness converts a weighted mean square to LUFS
+func loudness(power float64) float64 {
+	if power <= 0 {
+		return math.Inf(-1)
+	}
+	return -0.691 + 10*math.Log10(power)
+}
+
+// mean returns the average of values
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
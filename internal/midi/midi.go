@@ -0,0 +1,210 @@
+// Package midi writes Standard MIDI Files (.mid).
+//
+// Events are collected per track with absolute tick positions and written
+// as format 0 or format 1 files with delta times and running status left
+// out, which every sequencer and DAW reads.
+package midi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// Meta event types
+const (
+	MetaText          = 0x01
+	MetaTrackName     = 0x03
+	MetaMarker        = 0x06
+	MetaEndOfTrack    = 0x2F
+	MetaTempo         = 0x51
+	MetaTimeSignature = 0x58
+)
+
+// Event is a MIDI, SysEx or meta message at an absolute tick position. SysEx
+// messages start with 0xF0 and get their length when the file is written.
+type Event struct {
+	Tick int
+	Data []byte
+}
+
+// Track is one track of a MIDI file
+type Track struct {
+	Events []Event
+}
+
+// File is a Standard MIDI File. Division is the number of ticks per quarter note.
+type File struct {
+	Format   int
+	Division int
+	Tracks   []*Track
+}
+
+// NewFile creates an empty format 1 file
+func NewFile(division int) *File {
+	return &File{Format: 1, Division: division}
+}
+
+// AddTrack appends an empty track and returns it
+func (f *File) AddTrack() *Track {
+	t := &Track{}
+	f.Tracks = append(f.Tracks, t)
+	return t
+}
+
+// AddMeta appends a meta event
+func (t *Track) AddMeta(tick int, kind byte, data []byte) {
+	t.Events = append(t.Events, Event{Tick: tick, Data: MetaEvent(kind, data)})
+}
+
+// AddTrackName appends a track name meta event
+func (t *Track) AddTrackName(tick int, name string) {
+	t.AddMeta(tick, MetaTrackName, []byte(name))
+}
+
+// AddMarker appends a marker meta event
+func (t *Track) AddMarker(tick int, name string) {
+	t.AddMeta(tick, MetaMarker, []byte(name))
+}
+
+// AddTempo appends a tempo change in beats per minute
+func (t *Track) AddTempo(tick int, bpm float64) {
+	usPerQuarter := int(math.Round(60_000_000 / bpm))
+	if usPerQuarter > 0xFFFFFF {
+		usPerQuarter = 0xFFFFFF
+	}
+	t.AddMeta(tick, MetaTempo, []byte{byte(usPerQuarter >> 16), byte(usPerQuarter >> 8), byte(usPerQuarter)})
+}
+
+// AddTimeSignature appends a time signature change. The denominator must be
+// a power of two.
+func (t *Track) AddTimeSignature(tick, numerator, denominator int) {
+	power := 0
+	for d := denominator; d > 1; d >>= 1 {
+		power++
+	}
+	t.AddMeta(tick, MetaTimeSignature, []byte{byte(numerator), byte(power), 24, 8})
+}
+
+// Write encodes the file. Events of each track are sorted by tick, keeping
+// the order in which events at the same tick were added except that note-ons
+// come last, so a note ending where the same note starts again is not cut
+// off. Every track is closed with an end of track event.
+func (f *File) Write(w io.Writer) error {
+	if f.Division <= 0 || f.Division > 0x7FFF {
+		return fmt.Errorf("invalid division %d", f.Division)
+	}
+	if f.Format == 0 && len(f.Tracks) != 1 {
+		return fmt.Errorf("format 0 files hold exactly one track, got %d", len(f.Tracks))
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, 14)
+	copy(header, "MThd")
+	binary.BigEndian.PutUint32(header[4:], 6)
+	binary.BigEndian.PutUint16(header[8:], uint16(f.Format))
+	binary.BigEndian.PutUint16(header[10:], uint16(len(f.Tracks)))
+	binary.BigEndian.PutUint16(header[12:], uint16(f.Division))
+	bw.Write(header)
+
+	for i, t := range f.Tracks {
+		data, err := t.encode()
+		if err != nil {
+			return fmt.Errorf("track %d: %w", i+1, err)
+		}
+		chunk := make([]byte, 8)
+		copy(chunk, "MTrk")
+		binary.BigEndian.PutUint32(chunk[4:], uint32(len(data)))
+		bw.Write(chunk)
+		bw.Write(data)
+	}
+
+	return bw.Flush()
+}
+
+// WriteFile encodes the file to disk
+func (f *File) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// encode returns the body of a track chunk
+func (t *Track) encode() ([]byte, error) {
+	events := make([]Event, len(t.Events))
+	copy(events, t.Events)
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Tick != events[j].Tick {
+			return events[i].Tick < events[j].Tick
+		}
+		return !isNoteOn(events[i].Data) && isNoteOn(events[j].Data)
+	})
+
+	var buf bytes.Buffer
+	last := 0
+	for _, e := range events {
+		if e.Tick < 0 {
+			return nil, fmt.Errorf("event at negative tick %d", e.Tick)
+		}
+		if len(e.Data) == 0 {
+			continue
+		}
+
+		buf.Write(varLen(e.Tick - last))
+		last = e.Tick
+
+		switch e.Data[0] {
+		case 0xF0:
+			buf.WriteByte(0xF0)
+			body := e.Data[1:]
+			buf.Write(varLen(len(body)))
+			buf.Write(body)
+		default:
+			buf.Write(e.Data)
+		}
+	}
+
+	buf.Write([]byte{0, 0xFF, MetaEndOfTrack, 0})
+	return buf.Bytes(), nil
+}
+
+// MetaEvent encodes a meta event: 0xFF, the type, the length and the data
+func MetaEvent(kind byte, data []byte) []byte {
+	msg := []byte{0xFF, kind}
+	msg = append(msg, varLen(len(data))...)
+	return append(msg, data...)
+}
+
+// MessageLength returns the length of a channel message from its status byte,
+// or 0 for bytes that do not start a channel message
+func MessageLength(status byte) int {
+	switch status & 0xF0 {
+	case 0x80, 0x90, 0xA0, 0xB0, 0xE0:
+		return 3
+	case 0xC0, 0xD0:
+		return 2
+	}
+	return 0
+}
+
+// isNoteOn reports whether a message is a note-on with a non-zero velocity
+func isNoteOn(data []byte) bool {
+	return len(data) == 3 && data[0]&0xF0 == 0x90 && data[2] > 0
+}
+
+// varLen encodes a variable-length quantity
+func varLen(v int) []byte {
+	out := []byte{byte(v & 0x7F)}
+	for v >>= 7; v > 0; v >>= 7 {
+		out = append([]byte{byte(v&0x7F) | 0x80}, out...)
+	}
+	return out
+}
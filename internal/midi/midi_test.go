@@ -0,0 +1,151 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestVarLen(t *testing.T) {
+	tests := []struct {
+		v    int
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{0x40, []byte{0x40}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0x81, 0x00}},
+		{0x2000, []byte{0xC0, 0x00}},
+		{0x3FFF, []byte{0xFF, 0x7F}},
+		{0x4000, []byte{0x81, 0x80, 0x00}},
+		{0x1FFFFF, []byte{0xFF, 0xFF, 0x7F}},
+		{0x200000, []byte{0x81, 0x80, 0x80, 0x00}},
+		{0xFFFFFFF, []byte{0xFF, 0xFF, 0xFF, 0x7F}},
+	}
+	for _, tt := range tests {
+		if got := varLen(tt.v); !bytes.Equal(got, tt.want) {
+			t.Errorf("varLen(%#x) = % X, want % X", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestMetaEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(*Track)
+		want []byte
+	}{
+		{"tempo 120", func(tr *Track) { tr.AddTempo(0, 120) }, []byte{0xFF, 0x51, 0x03, 0x07, 0xA1, 0x20}},
+		{"tempo 140", func(tr *Track) { tr.AddTempo(0, 140) }, []byte{0xFF, 0x51, 0x03, 0x06, 0x8A, 0x1B}},
+		{"4/4", func(tr *Track) { tr.AddTimeSignature(0, 4, 4) }, []byte{0xFF, 0x58, 0x04, 0x04, 0x02, 0x18, 0x08}},
+		{"6/8", func(tr *Track) { tr.AddTimeSignature(0, 6, 8) }, []byte{0xFF, 0x58, 0x04, 0x06, 0x03, 0x18, 0x08}},
+		{"track name", func(tr *Track) { tr.AddTrackName(0, "Bass") }, []byte{0xFF, 0x03, 0x04, 'B', 'a', 's', 's'}},
+		{"marker", func(tr *Track) { tr.AddMarker(0, "Drop") }, []byte{0xFF, 0x06, 0x04, 'D', 'r', 'o', 'p'}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tr Track
+			tt.add(&tr)
+			if got := tr.Events[0].Data; !bytes.Equal(got, tt.want) {
+				t.Errorf("event = % X, want % X", got, tt.want)
+			}
+		})
+	}
+
+	// Long meta data gets a multi-byte length
+	long := MetaEvent(MetaText, make([]byte, 200))
+	if !bytes.Equal(long[:4], []byte{0xFF, 0x01, 0x81, 0x48}) || len(long) != 204 {
+		t.Errorf("long text event starts % X, length %d", long[:4], len(long))
+	}
+}
+
+func TestTrackEncoding(t *testing.T) {
+	var tr Track
+	tr.AddTrackName(0, "Keys")
+	tr.Events = append(tr.Events,
+		Event{Tick: 0, Data: []byte{0x90, 60, 100}},
+		// The repeated note is added before the note-off at the same tick
+		Event{Tick: 480, Data: []byte{0x90, 60, 90}},
+		Event{Tick: 480, Data: []byte{0x80, 60, 0}},
+		Event{Tick: 960, Data: []byte{0x90, 60, 0}},
+		Event{Tick: 960, Data: []byte{0xF0, 0x7E, 0x7F, 0x09, 0x01, 0xF7}},
+	)
+
+	got, err := tr.encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0x00, 0xFF, 0x03, 0x04, 'K', 'e', 'y', 's',
+		0x00, 0x90, 60, 100,
+		0x83, 0x60, 0x80, 60, 0, // note-off first at tick 480
+		0x00, 0x90, 60, 90,
+		0x83, 0x60, 0x90, 60, 0, // note-on with velocity 0 ends a note
+		0x00, 0xF0, 0x05, 0x7E, 0x7F, 0x09, 0x01, 0xF7,
+		0x00, 0xFF, 0x2F, 0x00,
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("track =\n% X\nwant\n% X", got, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	f := NewFile(960)
+	conductor := f.AddTrack()
+	conductor.AddTempo(0, 120)
+	conductor.AddTimeSignature(0, 3, 4)
+	notes := f.AddTrack()
+	notes.Events = append(notes.Events, Event{Tick: 0, Data: []byte{0x90, 64, 80}}, Event{Tick: 960, Data: []byte{0x80, 64, 0}})
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	header := []byte{'M', 'T', 'h', 'd', 0, 0, 0, 6, 0, 1, 0, 2, 0x03, 0xC0}
+	if !bytes.Equal(data[:14], header) {
+		t.Fatalf("header = % X, want % X", data[:14], header)
+	}
+
+	// Every track chunk's length matches its body, which ends with end of track
+	pos, tracks := 14, 0
+	for pos < len(data) {
+		if string(data[pos:pos+4]) != "MTrk" {
+			t.Fatalf("chunk at %d is %q, want MTrk", pos, data[pos:pos+4])
+		}
+		length := int(binary.BigEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + length
+		if end > len(data) {
+			t.Fatalf("track %d length %d runs past the file", tracks+1, length)
+		}
+		if eot := data[end-4 : end]; !bytes.Equal(eot, []byte{0x00, 0xFF, 0x2F, 0x00}) {
+			t.Errorf("track %d ends with % X, want end of track", tracks+1, eot)
+		}
+		pos = end
+		tracks++
+	}
+	if tracks != 2 {
+		t.Errorf("wrote %d tracks, want 2", tracks)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	f := &File{Format: 0, Division: 480}
+	f.AddTrack()
+	f.AddTrack()
+	if err := f.Write(&bytes.Buffer{}); err == nil {
+		t.Error("format 0 file with two tracks was written")
+	}
+
+	f = NewFile(0)
+	if err := f.Write(&bytes.Buffer{}); err == nil {
+		t.Error("file with division 0 was written")
+	}
+
+	f = NewFile(480)
+	f.AddTrack().Events = []Event{{Tick: -1, Data: []byte{0x90, 60, 100}}}
+	if err := f.Write(&bytes.Buffer{}); err == nil {
+		t.Error("event at a negative tick was written")
+	}
+}
@@ -0,0 +1,435 @@
+// Package rpp reads and writes REAPER project files (.RPP) and track
+// templates (.RTrackTemplate).
+//
+// An RPP file is a tree of chunks. A chunk starts with a line such as
+// "<TRACK {GUID}" and ends with a line containing only ">"; every other line
+// is a list of space-separated tokens, where tokens containing spaces are
+// quoted with ", ' or `. Unmodified lines are written back with their text
+// exactly as read so a parse/write round trip does not disturb parts of the
+// project the caller did not touch. Indentation is rewritten the way REAPER
+// writes it, two spaces per chunk level, and blank lines are dropped.
+package rpp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Node is a single line of an RPP file. Chunk nodes also hold child lines.
+type Node struct {
+	Name     string
+	Params   []string
+	Children []*Node
+
+	chunk bool
+	raw   string
+}
+
+// File is a parsed RPP document
+type File struct {
+	Nodes []*Node
+	crlf  bool
+}
+
+// NewNode creates a plain line node
+func NewNode(name string, params ...string) *Node {
+	return &Node{Name: name, Params: params}
+}
+
+// NewChunk creates an empty chunk node
+func NewChunk(name string, params ...string) *Node {
+	return &Node{Name: name, Params: params, chunk: true}
+}
+
+// NewRawNode creates a line that is written verbatim, such as a "|"-prefixed
+// notes line or a base64 data line
+func NewRawNode(text string) *Node {
+	fields := Tokenize(text)
+	n := &Node{raw: text}
+	if len(fields) > 0 {
+		n.Name = fields[0]
+		n.Params = fields[1:]
+	}
+	return n
+}
+
+// ParseFile reads and parses an RPP file from disk
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse parses an RPP document
+func Parse(r io.Reader) (*File, error) {
+	doc := &File{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	scanner.Split(scanLines)
+
+	var stack []*Node
+	lineNo := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNo++
+		if strings.HasSuffix(line, "\r") {
+			line = strings.TrimSuffix(line, "\r")
+			if lineNo == 1 {
+				doc.crlf = true
+			}
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if trimmed == ">" {
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: unexpected end of chunk", lineNo)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		var node *Node
+		if strings.HasPrefix(trimmed, "<") {
+			node = NewRawNode(trimmed[1:])
+			node.chunk = true
+			node.raw = trimmed
+		} else if strings.HasPrefix(trimmed, "|") {
+			// Notes lines are free text, trailing spaces included
+			node = NewRawNode(strings.TrimLeft(line, " \t"))
+		} else {
+			node = NewRawNode(trimmed)
+		}
+
+		if len(stack) == 0 {
+			doc.Nodes = append(doc.Nodes, node)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+		}
+
+		if node.chunk {
+			stack = append(stack, node)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unterminated chunk <%s", stack[len(stack)-1].Name)
+	}
+
+	return doc, nil
+}
+
+// scanLines splits input into lines like bufio.ScanLines, but keeps a
+// trailing "\r" so Parse can tell CRLF files apart
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// Project returns the top-level REAPER_PROJECT chunk, or nil if the document
+// is not a project (for example a track template)
+func (f *File) Project() *Node {
+	for _, n := range f.Nodes {
+		if n.chunk && n.Name == "REAPER_PROJECT" {
+			return n
+		}
+	}
+	return nil
+}
+
+// Chunks returns the top-level chunks with the given name
+func (f *File) Chunks(name string) []*Node {
+	var out []*Node
+	for _, n := range f.Nodes {
+		if n.chunk && n.Name == name {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// Write writes the document in RPP format
+func (f *File) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	newline := "\n"
+	if f.crlf {
+		newline = "\r\n"
+	}
+
+	for _, n := range f.Nodes {
+		n.write(bw, 0, newline)
+	}
+
+	return bw.Flush()
+}
+
+// WriteFile writes the document to path, replacing the file atomically
+func (f *File) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rpp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return os.Rename(tmpName, path)
+}
+
+func (n *Node) write(w *bufio.Writer, depth int, newline string) {
+	indent := strings.Repeat("  ", depth)
+	w.WriteString(indent)
+	w.WriteString(n.Line())
+	w.WriteString(newline)
+
+	if !n.chunk {
+		return
+	}
+	for _, c := range n.Children {
+		c.write(w, depth+1, newline)
+	}
+	w.WriteString(indent)
+	w.WriteString(">")
+	w.WriteString(newline)
+}
+
+// Line returns the node's text without indentation, including the leading
+// "<" for chunks
+func (n *Node) Line() string {
+	if n.raw != "" {
+		return n.raw
+	}
+
+	var b strings.Builder
+	if n.chunk {
+		b.WriteByte('<')
+	}
+	b.WriteString(n.Name)
+	for _, p := range n.Params {
+		b.WriteByte(' ')
+		b.WriteString(Quote(p))
+	}
+	return b.String()
+}
+
+// Text returns the line as it appeared in the file, or as it will be written
+func (n *Node) Text() string {
+	if n.chunk {
+		return strings.TrimPrefix(n.Line(), "<")
+	}
+	return n.Line()
+}
+
+// SetText replaces the line with text that is written verbatim
+func (n *Node) SetText(text string) {
+	replacement := NewRawNode(text)
+	n.Name, n.Params, n.raw = replacement.Name, replacement.Params, replacement.raw
+	if n.chunk {
+		n.raw = "<" + text
+	}
+}
+
+// IsChunk reports whether the node is a chunk with children
+func (n *Node) IsChunk() bool {
+	return n.chunk
+}
+
+// SetParams replaces the node's parameters
+func (n *Node) SetParams(params ...string) {
+	n.Params = params
+	n.raw = ""
+}
+
+// SetParam replaces a single parameter, growing the list with "0" if needed
+func (n *Node) SetParam(i int, value string) {
+	for len(n.Params) <= i {
+		n.Params = append(n.Params, "0")
+	}
+	n.Params[i] = value
+	n.raw = ""
+}
+
+// Param returns the i-th parameter or "" if it is missing
+func (n *Node) Param(i int) string {
+	if i < 0 || i >= len(n.Params) {
+		return ""
+	}
+	return n.Params[i]
+}
+
+// ParamFloat returns the i-th parameter as a float, or 0 if it is missing or invalid
+func (n *Node) ParamFloat(i int) float64 {
+	v, _ := strconv.ParseFloat(n.Param(i), 64)
+	return v
+}
+
+// ParamInt returns the i-th parameter as an int, or 0 if it is missing or invalid
+func (n *Node) ParamInt(i int) int {
+	v, err := strconv.Atoi(n.Param(i))
+	if err != nil {
+		return int(n.ParamFloat(i))
+	}
+	return v
+}
+
+// Child returns the first direct child with the given name, or nil
+func (n *Node) Child(name string) *Node {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// ChildrenNamed returns all direct children with the given name
+func (n *Node) ChildrenNamed(name string) []*Node {
+	var out []*Node
+	for _, c := range n.Children {
+		if c.Name == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// RemoveChildren removes all direct children for which drop returns true and
+// reports how many were removed
+func (n *Node) RemoveChildren(drop func(*Node) bool) int {
+	kept := n.Children[:0]
+	removed := 0
+	for _, c := range n.Children {
+		if drop(c) {
+			removed++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	n.Children = kept
+	return removed
+}
+
+// InsertChild inserts c at index i (clamped to the valid range)
+func (n *Node) InsertChild(i int, c *Node) {
+	if i < 0 {
+		i = 0
+	}
+	if i > len(n.Children) {
+		i = len(n.Children)
+	}
+	n.Children = append(n.Children, nil)
+	copy(n.Children[i+1:], n.Children[i:])
+	n.Children[i] = c
+}
+
+// Walk calls fn for n and every descendant in file order. Returning false
+// from fn skips the node's children.
+func (n *Node) Walk(fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+}
+
+// Tokenize splits an RPP line into tokens, honouring ", ' and ` quoting
+func Tokenize(line string) []string {
+	var tokens []string
+	i := 0
+	for i < len(line) {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i >= len(line) {
+			break
+		}
+
+		if q := line[i]; q == '"' || q == '\'' || q == '`' {
+			end := strings.IndexByte(line[i+1:], q)
+			if end < 0 {
+				tokens = append(tokens, line[i+1:])
+				break
+			}
+			tokens = append(tokens, line[i+1:i+1+end])
+			i += end + 2
+			continue
+		}
+
+		start := i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		tokens = append(tokens, line[start:i])
+	}
+	return tokens
+}
+
+// Quote returns s quoted as REAPER would write it. Tokens without spaces are
+// left bare; otherwise the first quote character not contained in s is used.
+func Quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t") && !strings.ContainsAny(s[:1], "\"'`") {
+		return s
+	}
+	for _, q := range []string{`"`, `'`, "`"} {
+		if !strings.Contains(s, q) {
+			return q + s + q
+		}
+	}
+	// REAPER replaces backticks when a string contains all three quote characters
+	return "`" + strings.ReplaceAll(s, "`", "'") + "`"
+}
+
+// FormatFloat formats a number the way REAPER writes positions and tempos
+func FormatFloat(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e10)/1e10, 'f', -1, 64)
+}
@@ -0,0 +1,246 @@
+package rpp
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// project is a trimmed REAPER 7 project with nested chunks, quoted and
+// backtick strings, notes lines and base64 plugin and MIDI data
+const project = `<REAPER_PROJECT 0.1 "7.22/macOS-arm64" 1727785200
+  <NOTES 0 2
+    |Lyrics: "city lights" it's late
+    |
+    |TODO: fix the bridge
+  >
+  RIPPLE 0
+  TEMPO 120 4 4
+  SAMPLERATE 48000 0 0
+  RENDER_FILE "/Users/me/Music/Renders"
+  RENDER_PATTERN $project-master
+  <TEMPOENVEX
+    EGUID {3C2B7F4E-1D8A-4A5B-9E0F-2C6D8B1A7E93}
+    ACT 1 -1
+    PT 0 120 1 262148
+    PT 8 140 0
+  >
+  MARKER 1 0 Intro 0 0 1 R {A1B2C3D4-0000-0000-0000-000000000001} 0
+  MARKER 2 16 "Verse 1" 1 0 1 R {A1B2C3D4-0000-0000-0000-000000000002} 0
+  MARKER 2 48 "" 1
+  MARKER 3 0 ` + "`Say \"hi\" it's me`" + ` 0
+  <TRACK {5F0C8D1B-7A6E-4C3B-8D2A-1E9F0B7C6A54}
+    NAME "Lead Vox"
+    PEAKCOL 16576
+    VOLPAN 1 0 -1 -1 1
+    <FXCHAIN
+      SHOW 0
+      BYPASS 0 0 0
+      <VST "VST: ReaEQ (Cockos)" reaeq.vst.dylib 0 "" 1919247729<5653547265716572656165710000> ""
+        cWVlcu5e7f4CAAAAAQAAAAAAAAACAAAAAAAAAAIAAAABAAAAAAAAAAIAAAAAAAAAVAAAAAEAAAA=
+        AAAQAAAA
+      >
+      FLOATPOS 0 0 0 0
+      WAK 0 0
+    >
+    <ITEM
+      POSITION 2.5
+      LENGTH 8
+      NAME 'vox take "best"'
+      <SOURCE WAVE
+        FILE "Audio/Lead Vox-001.wav"
+      >
+    >
+    <ITEM
+      POSITION 16
+      LENGTH 4
+      <SOURCE MIDI
+        HASDATA 1 960 QN
+        E 0 90 3c 60
+        E 480 80 3c 00
+        <X 0 0
+          /wMAAA==
+        >
+        CCINTERP 32
+      >
+    >
+  >
+>
+`
+
+func TestRoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name, input string
+	}{
+		{"lf", project},
+		{"crlf", strings.ReplaceAll(project, "\n", "\r\n")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := doc.Write(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.input {
+				t.Errorf("round trip changed the file:\n%s", buf.String())
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(strings.NewReader(project))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.Project()
+	if p == nil {
+		t.Fatal("no REAPER_PROJECT chunk")
+	}
+
+	track := p.Child("TRACK")
+	if got := track.Child("NAME").Param(0); got != "Lead Vox" {
+		t.Errorf("track name = %q", got)
+	}
+	vst := track.Child("FXCHAIN").Child("VST")
+	if got := vst.Param(0); got != "VST: ReaEQ (Cockos)" {
+		t.Errorf("plugin name = %q", got)
+	}
+	if got := len(vst.Children); got != 2 {
+		t.Errorf("plugin has %d data lines, want 2", got)
+	}
+
+	items := track.ChildrenNamed("ITEM")
+	if got := items[0].Child("NAME").Param(0); got != `vox take "best"` {
+		t.Errorf("item name = %q", got)
+	}
+	if got := items[0].Child("SOURCE").Child("FILE").Param(0); got != "Audio/Lead Vox-001.wav" {
+		t.Errorf("source file = %q", got)
+	}
+	if got := items[1].Child("SOURCE").Child("X"); got == nil || len(got.Children) != 1 {
+		t.Errorf("MIDI sysex chunk not parsed: %+v", got)
+	}
+
+	markers := p.ChildrenNamed("MARKER")
+	if got := markers[2].Param(2); got != "" {
+		t.Errorf("empty region end name = %q", got)
+	}
+	if got := markers[3].Param(2); got != `Say "hi" it's me` {
+		t.Errorf("backtick marker name = %q", got)
+	}
+}
+
+func TestEditKeepsOtherLines(t *testing.T) {
+	doc, err := Parse(strings.NewReader(project))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.Project()
+	p.Child("TEMPO").SetParam(0, "128")
+	p.ChildrenNamed("MARKER")[1].SetParam(2, "Verse One")
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Replace(project, "TEMPO 120 4 4", "TEMPO 128 4 4", 1)
+	want = strings.Replace(want, `MARKER 2 16 "Verse 1" 1 0 1 R`, `MARKER 2 16 "Verse One" 1 0 1 R`, 1)
+	if buf.String() != want {
+		t.Errorf("edit changed other lines:\n%s", buf.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"<REAPER_PROJECT\n  <TRACK\n  >\n",
+		"<REAPER_PROJECT\n>\n>\n",
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded", input)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`NAME "Lead Vox"`, []string{"NAME", "Lead Vox"}},
+		{`NAME 'say "hi"'`, []string{"NAME", `say "hi"`}},
+		{"NAME `say \"hi\" it's me`", []string{"NAME", `say "hi" it's me`}},
+		{`NAME "it's"`, []string{"NAME", "it's"}},
+		{`MARKER 2 48 "" 1`, []string{"MARKER", "2", "48", "", "1"}},
+		{"A  b\t c", []string{"A", "b", "c"}},
+		{`NAME "unterminated text`, []string{"NAME", "unterminated text"}},
+		{`|Lyrics: "city lights" it's late`, []string{"|Lyrics:", "city lights", "it's", "late"}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Vox", "Vox"},
+		{"", `""`},
+		{"Lead Vox", `"Lead Vox"`},
+		{`say "hi"`, `'say "hi"'`},
+		{"it's late", `"it's late"`},
+		{`say "hi" it's me`, "`say \"hi\" it's me`"},
+		{`"quoted"`, `'"quoted"'`},
+		{"`tick", "\"`tick\""},
+		// All three quote characters: backticks become apostrophes
+		{"`a` \"b\" 'c'", "`'a' \"b\" 'c'`"},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.in); got != tt.want {
+			t.Errorf("Quote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteTokenizeRoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"", "Vox", "Lead Vox", `say "hi"`, "it's", `say "hi" it's me`,
+		"`tick` and \"quote\"", "`tick` and 'apostrophe'", `"`, "'", "`",
+	} {
+		tokens := Tokenize("NAME " + Quote(s))
+		if len(tokens) != 2 || tokens[1] != s {
+			t.Errorf("Tokenize(Quote(%q)) = %q", s, tokens)
+		}
+	}
+}
+
+func TestNotesLinesKeepTrailingSpaces(t *testing.T) {
+	input := "<REAPER_PROJECT 0.1\n  <NOTES 0 2\n    |Chorus:  \n    |  indented\t\n    |\n  >\n  TEMPO 120 4 4\n>\n"
+	doc, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, c := range doc.Project().Child("NOTES").Children {
+		lines = append(lines, c.Text())
+	}
+	if want := []string{"|Chorus:  ", "|  indented\t", "|"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("notes lines = %q, want %q", lines, want)
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != input {
+		t.Errorf("round trip changed the notes:\n%q", buf.String())
+	}
+}
@@ -0,0 +1,212 @@
+package tool
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/music_project_manager/internal/audio"
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// Streaming services normalize to about -14 LUFS and recommend keeping true
+// peaks below -1 dBTP so lossy encoding does not clip
+const (
+	streamingTarget    = -14.0
+	streamingTolerance = 1.0
+	truePeakCeiling    = -1.0
+)
+
+// loudnessRow is one row of the analyze_render result table
+type loudnessRow struct {
+	Name       string `json:"name"`
+	Render     string `json:"render"`
+	Integrated string `json:"integrated"`
+	TruePeak   string `json:"true_peak"`
+	Range      string `json:"range"`
+	Duration   string `json:"duration"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+// analyzeRender measures the latest render of a project (or of every cataloged
+// project whose name contains name) and stores the result with the project.
+// Renders analyzed since they were last written are not decoded again.
+func (m *MusicProjectManagerTool) analyzeRender(name, path string) (string, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	projects, _ := loadCatalog(settings.ProjectDir)
+	var paths []string
+	if path != "" {
+		projectPath, err := m.resolveProjectPath("", path)
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, projectPath)
+	} else {
+		if projects == nil {
+			return "No projects found. Run 'scan' to update the project list", nil
+		}
+		// Sort a copy so the catalog keeps its order when saved
+		sorted := append([]types.Project(nil), projects...)
+		sortProjects(sorted, "date")
+		for _, p := range sorted {
+			if name == "" || strings.Contains(strings.ToLower(p.Name), strings.ToLower(name)) {
+				paths = append(paths, p.Path)
+			}
+		}
+		if len(paths) == 0 {
+			return fmt.Sprintf("No projects found matching '%s'", name), nil
+		}
+	}
+
+	var rows []loudnessRow
+	unrendered, quiet, hot := 0, 0, 0
+	changed := false
+	for _, projectPath := range paths {
+		doc, err := rpp.ParseFile(projectPath)
+		if err != nil {
+			continue
+		}
+		project := doc.Project()
+		if project == nil {
+			continue
+		}
+
+		renders := findRenders(project, projectPath, settings.RenderDir)
+		if len(renders) == 0 {
+			unrendered++
+			continue
+		}
+
+		row := loudnessRow{Name: displayName(projectPath), Render: renders[0].Path}
+		meta, err := readSidecar(projectPath)
+		if err != nil {
+			row.Notes = err.Error()
+			rows = append(rows, row)
+			continue
+		}
+		if meta == nil {
+			meta = &types.ProjectMetadata{}
+		}
+
+		l := meta.Loudness
+		if l == nil || l.File != renders[0].Path || l.AnalyzedAt.Before(renders[0].ModTime) {
+			measured, err := audio.Analyze(renders[0].Path)
+			if err != nil {
+				row.Notes = "cannot analyze: " + err.Error()
+				rows = append(rows, row)
+				continue
+			}
+			l = &types.RenderLoudness{
+				File:       renders[0].Path,
+				AnalyzedAt: time.Now(),
+				Integrated: roundTenth(measured.Integrated),
+				TruePeak:   roundTenth(measured.TruePeak),
+				Range:      roundTenth(measured.Range),
+				Duration:   roundMillis(measured.Duration),
+			}
+			meta.Loudness = l
+			if err := writeSidecar(projectPath, *meta); err != nil {
+				return "", err
+			}
+			changed = setCatalogLoudness(projects, projectPath, l) || changed
+		}
+
+		row.Integrated = fmt.Sprintf("%.1f LUFS", l.Integrated)
+		row.TruePeak = fmt.Sprintf("%.1f dBTP", l.TruePeak)
+		row.Range = fmt.Sprintf("%.1f LU", l.Range)
+		row.Duration = formatSeconds(l.Duration)
+		notes := loudnessNotes(l)
+		row.Notes = strings.Join(notes, ", ")
+		if l.Integrated < streamingTarget-streamingTolerance {
+			quiet++
+		}
+		if l.TruePeak > truePeakCeiling {
+			hot++
+		}
+		rows = append(rows, row)
+	}
+
+	if changed {
+		if err := saveCatalog(settings.ProjectDir, projects); err != nil {
+			log.Printf("[music-project-manager] Warning: failed to update projects.json: %v", err)
+		}
+	}
+
+	if len(rows) == 0 {
+		if len(paths) == 1 {
+			return fmt.Sprintf("%s has no render to analyze", displayName(paths[0])), nil
+		}
+		return fmt.Sprintf("None of the %s have a render to analyze", plural(len(paths), "project")), nil
+	}
+
+	result := pluginapi.NewTableResult(
+		"Render Loudness",
+		[]string{"Name", "Render", "Integrated", "True Peak", "LRA", "Duration", "Notes"},
+		rows,
+	)
+	desc := fmt.Sprintf("%s analyzed: %d below the %.0f LUFS streaming target, %d with true peak above %.0f dBTP",
+		plural(len(rows), "render"), quiet, streamingTarget, hot, truePeakCeiling)
+	if unrendered > 0 {
+		desc += fmt.Sprintf(". %s never rendered", plural(unrendered, "project"))
+	}
+	result.Description = desc
+
+	return result.ToJSON()
+}
+
+// loudnessNotes explains how a render compares with streaming loudness
+// targets, e.g. "too quiet for streaming, 5.2 LU below -14 LUFS"
+func loudnessNotes(l *types.RenderLoudness) []string {
+	var notes []string
+	switch diff := l.Integrated - streamingTarget; {
+	case l.Integrated <= -70:
+		notes = append(notes, "silent")
+	case diff < -streamingTolerance:
+		notes = append(notes, fmt.Sprintf("too quiet for streaming, %.1f LU below %.0f LUFS", -diff, streamingTarget))
+	case diff > streamingTolerance:
+		notes = append(notes, fmt.Sprintf("streaming services turn it down %.1f dB", diff))
+	}
+	if l.TruePeak > truePeakCeiling {
+		notes = append(notes, fmt.Sprintf("true peak above %.0f dBTP", truePeakCeiling))
+	}
+	return notes
+}
+
+// describeLoudness formats a render's loudness, e.g. "-9.8 LUFS, -0.2 dBTP true peak, 5.1 LU range"
+func describeLoudness(l *types.RenderLoudness) string {
+	desc := fmt.Sprintf("%.1f LUFS, %.1f dBTP true peak, %.1f LU range", l.Integrated, l.TruePeak, l.Range)
+	if notes := loudnessNotes(l); len(notes) > 0 {
+		desc += " (" + strings.Join(notes, ", ") + ")"
+	}
+	return desc
+}
+
+// setCatalogLoudness records a measurement on the cataloged project at
+// projectPath and reports whether one was found
+func setCatalogLoudness(projects []types.Project, projectPath string, l *types.RenderLoudness) bool {
+	for i := range projects {
+		if filepath.Clean(projects[i].Path) == filepath.Clean(projectPath) {
+			projects[i].Loudness = l
+			return true
+		}
+	}
+	return false
+}
+
+// roundTenth rounds a level to 0.1 dB
+func roundTenth(v float64) float64 {
+	return math.Round(v*10) / 10
+}
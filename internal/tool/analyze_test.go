@@ -0,0 +1,28 @@
+package tool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+func TestLoudnessNotes(t *testing.T) {
+	tests := []struct {
+		name string
+		l    types.RenderLoudness
+		want []string
+	}{
+		{"silent", types.RenderLoudness{Integrated: -70, TruePeak: -120}, []string{"silent"}},
+		{"quiet", types.RenderLoudness{Integrated: -19.2, TruePeak: -3}, []string{"too quiet for streaming, 5.2 LU below -14 LUFS"}},
+		{"on target", types.RenderLoudness{Integrated: -14.5, TruePeak: -1.5}, nil},
+		{"loud and hot", types.RenderLoudness{Integrated: -8, TruePeak: 0.3}, []string{"streaming services turn it down 6.0 dB", "true peak above -1 dBTP"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loudnessNotes(&tt.l); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loudnessNotes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
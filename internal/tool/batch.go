@@ -0,0 +1,259 @@
+package tool
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+	"github.com/johnjallday/ori-agent/pluginapi"
+	"gopkg.in/yaml.v3"
+)
+
+// batchSong is one row of a create_batch spec file
+type batchSong struct {
+	Name           string   `json:"name" yaml:"name"`
+	BPM            int      `json:"bpm" yaml:"bpm"`
+	Key            string   `json:"key" yaml:"key"`
+	Template       string   `json:"template" yaml:"template"`
+	Tags           specList `json:"tags" yaml:"tags"`
+	TimeSignature  string   `json:"time_signature" yaml:"time_signature"`
+	TrackTemplates specList `json:"track_templates" yaml:"track_templates"`
+}
+
+// specList is a list in a spec file that may also be written as a single
+// comma- or semicolon-separated string
+type specList []string
+
+// UnmarshalJSON accepts a JSON array or string
+func (l *specList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("expected a list or a comma-separated string")
+	}
+	*l = splitSpecList(text)
+	return nil
+}
+
+// UnmarshalYAML accepts a YAML sequence or string
+func (l *specList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*l = list
+		return nil
+	}
+	var text string
+	if err := node.Decode(&text); err != nil {
+		return fmt.Errorf("line %d: expected a list or a comma-separated string", node.Line)
+	}
+	*l = splitSpecList(text)
+	return nil
+}
+
+// splitSpecList splits "genre:trap, album:summer" or "genre:trap; album:summer"
+func splitSpecList(text string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ';' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// batchResult is one row of the create_batch result table
+type batchResult struct {
+	Row     int    `json:"row"`
+	Name    string `json:"name"`
+	Result  string `json:"result"`
+	Details string `json:"details"`
+}
+
+// createBatch creates every song listed in a YAML, JSON or CSV spec file.
+// Projects are not launched unless launch is explicitly true.
+func (m *MusicProjectManagerTool) createBatch(params types.MusicProjectParams) (string, error) {
+	if params.Path == "" {
+		return "", fmt.Errorf("path to a .yaml, .json or .csv spec file is required")
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" || settings.TemplateDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir and template_dir in the application settings.", nil
+	}
+
+	songs, err := readBatchSpec(params.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if len(songs) == 0 {
+		return fmt.Sprintf("No songs found in %s", params.Path), nil
+	}
+
+	launch := params.Launch != nil && *params.Launch
+	sharedTags := withTag(params.Tags, params.Tag)
+
+	results := make([]batchResult, 0, len(songs))
+	created := 0
+	for i, song := range songs {
+		template := song.Template
+		if template == "" {
+			template = params.Template
+		}
+
+		row := types.MusicProjectParams{
+			Operation:      "create_project",
+			Name:           strings.TrimSpace(song.Name),
+			BPM:            song.BPM,
+			Key:            song.Key,
+			Template:       template,
+			TimeSignature:  song.TimeSignature,
+			TrackTemplates: song.TrackTemplates,
+			Tags:           append(append([]string{}, sharedTags...), song.Tags...),
+			NamingScheme:   params.NamingScheme,
+			OnConflict:     params.OnConflict,
+			Launch:         &launch,
+		}
+
+		result := batchResult{Row: i + 1, Name: row.Name}
+		project, err := m.newProject(settings, row)
+		switch {
+		case err != nil:
+			result.Result = "failed"
+			result.Details = err.Error()
+		case project.Existing:
+			result.Name = project.Name
+			result.Result = "existing"
+			result.Details = project.Path
+		default:
+			result.Name = project.Name
+			result.Result = "created"
+			result.Details = project.Path
+			created++
+		}
+		results = append(results, result)
+	}
+
+	table := pluginapi.NewTableResult(
+		"Batch Create",
+		[]string{"Row", "Name", "Result", "Details"},
+		results,
+	)
+	table.Description = fmt.Sprintf("Created %d of %d projects from %s", created, len(songs), filepath.Base(params.Path))
+
+	return table.ToJSON()
+}
+
+// readBatchSpec reads the songs from a .yaml/.yml, .json or .csv spec file.
+// YAML and JSON specs are either a list of songs or an object with a "songs" list.
+func readBatchSpec(path string) ([]batchSong, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file %s: %w", path, err)
+	}
+
+	var songs []batchSong
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var wrapper struct {
+			Songs []batchSong `yaml:"songs"`
+		}
+		if err := yaml.Unmarshal(data, &songs); err != nil {
+			if wrapErr := yaml.Unmarshal(data, &wrapper); wrapErr != nil {
+				return nil, fmt.Errorf("failed to parse YAML spec %s: %w", path, err)
+			}
+			songs = wrapper.Songs
+		}
+	case ".json":
+		var wrapper struct {
+			Songs []batchSong `json:"songs"`
+		}
+		if err := json.Unmarshal(data, &songs); err != nil {
+			if wrapErr := json.Unmarshal(data, &wrapper); wrapErr != nil {
+				return nil, fmt.Errorf("failed to parse JSON spec %s: %w", path, err)
+			}
+			songs = wrapper.Songs
+		}
+	case ".csv":
+		songs, err = parseBatchCSV(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV spec %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("spec file must be .yaml, .yml, .json or .csv, got %s", filepath.Ext(path))
+	}
+
+	return songs, nil
+}
+
+// parseBatchCSV parses a CSV spec whose header names the columns
+// (name, bpm, key, template, tags, time_signature, track_templates)
+func parseBatchCSV(data []byte) ([]batchSong, error) {
+	// Excel saves "CSV UTF-8" with a byte order mark, which would otherwise
+	// stick to the first column name
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff")))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("header row must include a 'name' column")
+	}
+
+	field := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var songs []batchSong
+	for line, record := range records[1:] {
+		song := batchSong{
+			Name:           field(record, "name"),
+			Key:            field(record, "key"),
+			Template:       field(record, "template"),
+			Tags:           splitSpecList(field(record, "tags")),
+			TimeSignature:  field(record, "time_signature"),
+			TrackTemplates: splitSpecList(field(record, "track_templates")),
+		}
+		if bpm := field(record, "bpm"); bpm != "" {
+			value, err := strconv.ParseFloat(bpm, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid BPM %q", line+1, bpm)
+			}
+			song.BPM = int(value + 0.5)
+		}
+		songs = append(songs, song)
+	}
+
+	return songs, nil
+}
@@ -0,0 +1,45 @@
+package tool
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBatchCSV(t *testing.T) {
+	want := []batchSong{
+		{Name: "Night Drive", BPM: 92, Key: "F minor", Tags: []string{"album:summer", "mood:dark"}},
+		{Name: "Sunrise", BPM: 124},
+	}
+	body := "Night Drive,91.6,F minor,album:summer; mood:dark\nSunrise,124,,\n"
+
+	tests := []struct {
+		name, header string
+	}{
+		{"plain", "name,bpm,key,tags\n"},
+		{"byte order mark", "\ufeffname,bpm,key,tags\n"},
+		{"byte order mark before a quoted header", "\ufeff\"Name\",BPM,Key,Tags\n"},
+		{"crlf", "name,bpm,key,tags\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs, err := parseBatchCSV([]byte(tt.header + body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(songs, want) {
+				t.Errorf("songs = %+v, want %+v", songs, want)
+			}
+		})
+	}
+}
+
+func TestParseBatchCSVErrors(t *testing.T) {
+	for _, data := range []string{
+		"title,bpm\nNight Drive,92\n",
+		"name,bpm\nNight Drive,fast\n",
+	} {
+		if _, err := parseBatchCSV([]byte(data)); err == nil {
+			t.Errorf("parseBatchCSV(%q) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package tool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+)
+
+// backupDirName is the folder inside a project folder that holds backups,
+// the same folder REAPER writes its own .rpp-bak files to
+const backupDirName = "Backups"
+
+// rescaleStats counts what rescaleProject moved
+type rescaleStats struct {
+	Items    int
+	Stretch  int
+	Markers  int
+	Envelope int
+}
+
+// setBPM changes the tempo of an existing project. The header tempo and every
+// tempo envelope point are scaled by the same factor so tempo changes keep their
+// shape. With rescale, items, markers and automation move with the tempo so
+// the arrangement stays on the grid; audio items are time-stretched.
+func (m *MusicProjectManagerTool) setBPM(name, path string, bpm int, rescale bool) (string, error) {
+	if bpm < 30 || bpm > 300 {
+		return "", fmt.Errorf("BPM must be between 30 and 300, got %d", bpm)
+	}
+
+	projectPath, err := m.resolveProjectPath(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return "", fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	oldBPM := readTempoMap(project).Initial()
+	if oldBPM == float64(bpm) {
+		return fmt.Sprintf("%s is already at %d BPM", displayName(projectPath), bpm), nil
+	}
+
+	backup, err := backupProject(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	factor := float64(bpm) / oldBPM
+
+	tempo := project.Child("TEMPO")
+	if tempo == nil {
+		tempo = rpp.NewNode("TEMPO", "120", "4", "4")
+		project.InsertChild(len(project.Children), tempo)
+	}
+	tempo.SetParam(0, strconv.Itoa(bpm))
+
+	if env := project.Child("TEMPOENVEX"); env != nil {
+		for _, pt := range env.ChildrenNamed("PT") {
+			pt.SetParam(1, rpp.FormatFloat(pt.ParamFloat(1)*factor))
+			if rescale {
+				pt.SetParam(0, rpp.FormatFloat(pt.ParamFloat(0)/factor))
+			}
+		}
+	}
+
+	var stats rescaleStats
+	if rescale {
+		stats = rescaleProject(project, factor)
+	}
+
+	if err := doc.WriteFile(projectPath); err != nil {
+		return "", fmt.Errorf("failed to write project file: %w", err)
+	}
+
+	if settings, err := m.loadSettings(); err == nil && settings.ProjectDir != "" {
+		refreshCatalogEntry(settings.ProjectDir, projectPath)
+	}
+
+	msg := fmt.Sprintf("Changed tempo of %s from %s to %d BPM", displayName(projectPath), strconv.FormatFloat(oldBPM, 'f', -1, 64), bpm)
+	if rescale {
+		msg += fmt.Sprintf("\nRescaled %d items (%d audio items time-stretched), %d markers and %d automation points", stats.Items, stats.Stretch, stats.Markers, stats.Envelope)
+	} else {
+		msg += "\nItems and markers kept their positions in seconds. Use rescale to keep the arrangement on the grid"
+	}
+	msg += fmt.Sprintf("\nBackup: %s", backup)
+	return msg, nil
+}
+
+// rescaleProject moves every time position in the project by 1/factor so it
+// stays at the same musical position after all tempos were multiplied by factor
+func rescaleProject(project *rpp.Node, factor float64) rescaleStats {
+	var stats rescaleStats
+
+	scale := func(n *rpp.Node, i int) {
+		if i < len(n.Params) {
+			n.SetParam(i, rpp.FormatFloat(n.ParamFloat(i)/factor))
+		}
+	}
+
+	for _, c := range project.Children {
+		switch c.Name {
+		case "MARKER":
+			scale(c, 1)
+			stats.Markers++
+		case "SELECTION", "SELECTION2":
+			scale(c, 0)
+			scale(c, 1)
+		case "CURSOR":
+			scale(c, 0)
+		}
+	}
+
+	project.Walk(func(n *rpp.Node) bool {
+		switch {
+		case n.Name == "TEMPOENVEX":
+			// Already handled together with the tempo
+			return false
+		case n.IsChunk() && n.Name == "ITEM":
+			rescaleItem(n, factor, &stats)
+		case n.IsChunk() && strings.Contains(n.Name, "ENV"):
+			for _, pt := range n.ChildrenNamed("PT") {
+				scale(pt, 0)
+				stats.Envelope++
+			}
+		}
+		return true
+	})
+
+	return stats
+}
+
+// rescaleItem moves and resizes an item; audio takes are time-stretched with
+// pitch preserved so they keep playing in time. MIDI follows the tempo by itself.
+func rescaleItem(item *rpp.Node, factor float64, stats *rescaleStats) {
+	for _, c := range item.Children {
+		switch c.Name {
+		case "POSITION", "LENGTH", "SNAPOFFS":
+			c.SetParam(0, rpp.FormatFloat(c.ParamFloat(0)/factor))
+		case "FADEIN", "FADEOUT":
+			if len(c.Params) > 1 {
+				c.SetParam(1, rpp.FormatFloat(c.ParamFloat(1)/factor))
+			}
+		}
+	}
+	stats.Items++
+
+	if !hasAudioSource(item) {
+		return
+	}
+
+	for _, rate := range item.ChildrenNamed("PLAYRATE") {
+		rate.SetParam(0, rpp.FormatFloat(rate.ParamFloat(0)*factor))
+		// Second field turns on "preserve pitch when changing rate"
+		rate.SetParam(1, "1")
+	}
+	stats.Stretch++
+}
+
+// hasAudioSource reports whether an item plays an audio file rather than MIDI
+func hasAudioSource(item *rpp.Node) bool {
+	audio := false
+	for _, src := range item.ChildrenNamed("SOURCE") {
+		kind := strings.ToUpper(src.Param(0))
+		if kind == "SECTION" {
+			if inner := src.Child("SOURCE"); inner != nil {
+				kind = strings.ToUpper(inner.Param(0))
+			}
+		}
+		if kind != "" && kind != "MIDI" && kind != "MIDIPOOL" && kind != "EMPTY" {
+			audio = true
+		}
+	}
+	return audio
+}
+
+// backupProject copies a project file to <project folder>/Backups/<name>-<timestamp>.RPP-bak
+func backupProject(projectPath string) (string, error) {
+	data, err := os.ReadFile(projectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read project file for backup: %w", err)
+	}
+
+	dir := filepath.Join(filepath.Dir(projectPath), backupDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup folder %s: %w", dir, err)
+	}
+
+	base := fmt.Sprintf("%s-%s", displayName(projectPath), time.Now().Format("2006-01-02_150405"))
+	backup := filepath.Join(dir, base+".RPP-bak")
+	// Never overwrite an earlier backup taken within the same second
+	for i := 2; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = filepath.Join(dir, fmt.Sprintf("%s-%d.RPP-bak", base, i))
+	}
+
+	if err := os.WriteFile(backup, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write backup %s: %w", backup, err)
+	}
+
+	return backup, nil
+}
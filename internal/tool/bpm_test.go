@@ -0,0 +1,332 @@
+package tool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// constantTempoProject is a 120 BPM project with an audio item, a MIDI item,
+// a marker, a region and a volume envelope
+const constantTempoProject = `<REAPER_PROJECT 0.1 "7.22/macOS-arm64" 1727785200
+  TEMPO 120 4 4
+  MARKER 1 0 Intro 0 0 1
+  MARKER 2 8 Verse 0 0 1
+  MARKER 3 16 Chorus 1 0 1
+  MARKER 3 32 "" 1
+  <TRACK
+    NAME Drums
+    <VOLENV2
+      PT 0 1 0
+      PT 8 0.5 0
+    >
+    <ITEM
+      POSITION 2
+      SNAPOFFS 0
+      LENGTH 4
+      FADEIN 1 0.01 0
+      FADEOUT 1 0.5 0
+      PLAYRATE 1 0 0 -1 0 0.0025
+      <SOURCE WAVE
+        FILE "Audio/Drums.wav"
+      >
+    >
+    <ITEM
+      POSITION 8.5
+      LENGTH 1.25
+      <SOURCE MIDI
+        HASDATA 1 960 QN
+        E 0 90 3c 60
+        E 480 80 3c 00
+      >
+    >
+  >
+>
+`
+
+// tempoMapProject starts at 100 BPM, ramps to 140 BPM, holds it and switches
+// to 3/4 at 20 seconds
+const tempoMapProject = `<REAPER_PROJECT 0.1 "7.22/macOS-arm64" 1727785200
+  TEMPO 100 4 4
+  <TEMPOENVEX
+    ACT 1 -1
+    PT 0 100 0
+    PT 9.6 140 1
+    PT 20 140 1 262147
+  >
+  MARKER 1 4.8 Build 0 0 1
+  MARKER 2 12.3 Drop 0 0 1
+  MARKER 3 20 Outro 1 0 1
+  MARKER 3 27.5 "" 1
+  <TRACK
+    NAME Pad
+    <ITEM
+      POSITION 3.2
+      LENGTH 8
+      PLAYRATE 1 1 0 -1 0 0.0025
+      <SOURCE SECTION
+        LENGTH 8
+        <SOURCE WAVE
+          FILE "Audio/Pad.wav"
+        >
+      >
+    >
+    <ITEM
+      POSITION 21.25
+      LENGTH 3.5
+      <SOURCE MIDI
+        HASDATA 1 960 QN
+      >
+    >
+  >
+>
+`
+
+// writeTestProject writes a project file into a new project folder
+func writeTestProject(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "Song", "Song.RPP")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// parseTestProject parses a project file and returns its project chunk
+func parseTestProject(t *testing.T, path string) *rpp.Node {
+	t.Helper()
+	doc, err := rpp.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc.Project()
+}
+
+// musicalPositions returns the bar.beat position of every marker, region end
+// and item start and end in a project, keyed by a label
+func musicalPositions(project *rpp.Node) map[string]string {
+	tm := readTempoMap(project)
+	positions := make(map[string]string)
+
+	for i, marker := range project.ChildrenNamed("MARKER") {
+		positions[fmt.Sprintf("marker line %d", i+1)] = tm.Position(marker.ParamFloat(1))
+	}
+
+	i := 0
+	project.Walk(func(n *rpp.Node) bool {
+		if n.IsChunk() && n.Name == "ITEM" {
+			i++
+			start := n.Child("POSITION").ParamFloat(0)
+			end := start + n.Child("LENGTH").ParamFloat(0)
+			positions[fmt.Sprintf("item %d start", i)] = tm.Position(start)
+			positions[fmt.Sprintf("item %d end", i)] = tm.Position(end)
+			return false
+		}
+		return true
+	})
+
+	return positions
+}
+
+// itemPlayRates returns the play rate of every item in a project
+func itemPlayRates(project *rpp.Node) []string {
+	var rates []string
+	project.Walk(func(n *rpp.Node) bool {
+		if n.IsChunk() && n.Name == "ITEM" {
+			rate := ""
+			if c := n.Child("PLAYRATE"); c != nil {
+				rate = c.Param(0)
+			}
+			rates = append(rates, rate)
+			return false
+		}
+		return true
+	})
+	return rates
+}
+
+func TestSetBPMRescale(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+		bpm     int
+		// tempos of the rescaled tempo map and the play rates of its items
+		tempos []float64
+		rates  []string
+	}{
+		{"constant tempo", constantTempoProject, 90, []float64{90}, []string{"0.75", ""}},
+		{"tempo map", tempoMapProject, 120, []float64{120, 168, 168}, []string{"1.2", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestProject(t, tt.project)
+			before := parseTestProject(t, path)
+			want := musicalPositions(before)
+			oldMap := readTempoMap(before)
+
+			m := &MusicProjectManagerTool{settings: &types.Settings{}}
+			if _, err := m.setBPM("", path, tt.bpm, true); err != nil {
+				t.Fatal(err)
+			}
+
+			after := parseTestProject(t, path)
+			got := musicalPositions(after)
+			for label, pos := range want {
+				if got[label] != pos {
+					t.Errorf("%s moved from %s to %s", label, pos, got[label])
+				}
+			}
+
+			newMap := readTempoMap(after)
+			if len(newMap.Points) != len(tt.tempos) {
+				t.Fatalf("tempo map has %d points, want %d", len(newMap.Points), len(tt.tempos))
+			}
+			for i, p := range newMap.Points {
+				if p.BPM != tt.tempos[i] {
+					t.Errorf("point %d = %v BPM, want %v", i+1, p.BPM, tt.tempos[i])
+				}
+				// Tempo changes stay on the same beat and keep their shape and time signature
+				old := oldMap.Points[i]
+				if got, want := newMap.Position(p.Time), oldMap.Position(old.Time); got != want {
+					t.Errorf("point %d moved from %s to %s", i+1, want, got)
+				}
+				if p.Shape != old.Shape || p.Numerator != old.Numerator || p.Denominator != old.Denominator {
+					t.Errorf("point %d = %+v, was %+v", i+1, p, old)
+				}
+			}
+
+			// Audio items are time-stretched, MIDI items follow the tempo
+			rates := itemPlayRates(after)
+			for i := range tt.rates {
+				if rates[i] != tt.rates[i] {
+					t.Errorf("item %d play rate = %q, want %q", i+1, rates[i], tt.rates[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSetBPMRescaleEnvelopesAndFades(t *testing.T) {
+	path := writeTestProject(t, constantTempoProject)
+	m := &MusicProjectManagerTool{settings: &types.Settings{}}
+	if _, err := m.setBPM("", path, 60, true); err != nil {
+		t.Fatal(err)
+	}
+
+	track := parseTestProject(t, path).Child("TRACK")
+	if got := track.Child("VOLENV2").ChildrenNamed("PT")[1].Param(0); got != "16" {
+		t.Errorf("envelope point at %s, want 16", got)
+	}
+
+	item := track.Child("ITEM")
+	for _, tt := range []struct {
+		line  string
+		param int
+		want  string
+	}{
+		{"POSITION", 0, "4"},
+		{"LENGTH", 0, "8"},
+		{"FADEIN", 1, "0.02"},
+		{"FADEOUT", 1, "1"},
+	} {
+		if got := item.Child(tt.line).Param(tt.param); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.line, got, tt.want)
+		}
+	}
+	if got := item.Child("PLAYRATE").Param(1); got != "1" {
+		t.Errorf("preserve pitch = %s, want 1", got)
+	}
+}
+
+func TestSetBPMWithoutRescale(t *testing.T) {
+	path := writeTestProject(t, tempoMapProject)
+	m := &MusicProjectManagerTool{settings: &types.Settings{}}
+	msg, err := m.setBPM("", path, 120, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the tempo map changes: items and markers keep their times in seconds
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer(
+		"TEMPO 100 4 4", "TEMPO 120 4 4",
+		"PT 0 100 0", "PT 0 120 0",
+		"PT 9.6 140 1", "PT 9.6 168 1",
+		"PT 20 140 1 262147", "PT 20 168 1 262147",
+	).Replace(tempoMapProject)
+	if string(data) != want {
+		t.Errorf("project after set_bpm without rescale:\n%s", data)
+	}
+
+	// The original is kept as a backup
+	backups, _ := filepath.Glob(filepath.Join(filepath.Dir(path), backupDirName, "Song-*.RPP-bak"))
+	if len(backups) != 1 {
+		t.Fatalf("found %d backups, want 1", len(backups))
+	}
+	if backup, _ := os.ReadFile(backups[0]); string(backup) != tempoMapProject {
+		t.Error("backup differs from the original project")
+	}
+	if !strings.Contains(msg, "Backup: "+backups[0]) {
+		t.Errorf("message does not name the backup: %s", msg)
+	}
+}
+
+func TestSetBPMInvalid(t *testing.T) {
+	path := writeTestProject(t, constantTempoProject)
+	m := &MusicProjectManagerTool{settings: &types.Settings{}}
+
+	for _, bpm := range []int{29, 301} {
+		if _, err := m.setBPM("", path, bpm, true); err == nil {
+			t.Errorf("setBPM(%d) succeeded", bpm)
+		}
+	}
+
+	msg, err := m.setBPM("", path, 120, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg, "already at 120 BPM") {
+		t.Errorf("message = %q", msg)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), backupDirName)); !os.IsNotExist(err) {
+		t.Error("backup taken although nothing changed")
+	}
+}
+
+func TestTempoMapPosition(t *testing.T) {
+	tm := readTempoMap(parseTestProject(t, writeTestProject(t, tempoMapProject)))
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{0, "1.1.00"},
+		// 100 to 140 BPM over 9.6 s covers 19.2 quarter notes
+		{9.6, "5.4.20"},
+		// 140 BPM from 9.6 s: 24.27 more quarter notes by 20 s, so the 3/4
+		// bar starts at bar 12
+		{20, "12.1.00"},
+		{20 + 3*60.0/140, "13.1.00"},
+	}
+	for _, tt := range tests {
+		if got := tm.Position(tt.seconds); got != tt.want {
+			t.Errorf("Position(%v) = %s, want %s", tt.seconds, got, tt.want)
+		}
+	}
+	for _, qn := range []float64{0, 3, 19.2, 30, 50} {
+		if got := tm.QuarterNotesAt(tm.SecondsAt(qn)); got < qn-1e-9 || got > qn+1e-9 {
+			t.Errorf("QuarterNotesAt(SecondsAt(%v)) = %v", qn, got)
+		}
+	}
+}
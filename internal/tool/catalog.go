@@ -0,0 +1,276 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// loadCatalog reads and parses projects.json from the project directory
+func loadCatalog(projectDir string) ([]types.Project, error) {
+	projectsFile := filepath.Join(projectDir, "projects.json")
+
+	data, err := os.ReadFile(projectsFile)
+	if err != nil {
+		return nil, fmt.Errorf("projects.json not found at %s. Run 'scan' operation first", projectsFile)
+	}
+
+	var projects []types.Project
+	if err := json.Unmarshal(data, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse projects.json: %w", err)
+	}
+
+	return projects, nil
+}
+
+// saveCatalog writes the projects back to projects.json in the project directory
+func saveCatalog(projectDir string, projects []types.Project) error {
+	projectsFile := filepath.Join(projectDir, "projects.json")
+
+	projectsData, err := json.MarshalIndent(projects, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal projects data: %w", err)
+	}
+
+	if err := os.WriteFile(projectsFile, projectsData, 0o644); err != nil {
+		return fmt.Errorf("failed to write projects.json: %w", err)
+	}
+
+	return nil
+}
+
+// findProject returns the index of the project matching name.
+// An exact (case-insensitive) match wins; otherwise the name must match
+// exactly one project as a substring.
+func findProject(projects []types.Project, name string) (int, error) {
+	if name == "" {
+		return -1, fmt.Errorf("project name is required")
+	}
+
+	for i, proj := range projects {
+		if strings.EqualFold(proj.Name, name) {
+			return i, nil
+		}
+	}
+
+	var matches []int
+	searchLower := strings.ToLower(name)
+	for i, proj := range projects {
+		if strings.Contains(strings.ToLower(proj.Name), searchLower) {
+			matches = append(matches, i)
+		}
+	}
+
+	if len(matches) == 0 {
+		return -1, fmt.Errorf("no project found matching '%s'. Try running 'scan' to update the project list", name)
+	}
+
+	if len(matches) > 1 {
+		var matchNames []string
+		for _, i := range matches {
+			matchNames = append(matchNames, projects[i].Name)
+		}
+		return -1, fmt.Errorf("multiple projects found matching '%s': %s. Please be more specific", name, strings.Join(matchNames, ", "))
+	}
+
+	return matches[0], nil
+}
+
+// updateProject finds a project by name in the catalog, applies update to it
+// and saves both its metadata sidecar and the catalog. It returns the updated project.
+func updateProject(projectDir, name string, update func(*types.Project) error) (*types.Project, error) {
+	projects, err := loadCatalog(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, err := findProject(projects, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := update(&projects[idx]); err != nil {
+		return nil, err
+	}
+
+	// The sidecar is the source of truth, so write it before the catalog
+	if err := writeSidecar(projects[idx].Path, projects[idx].ProjectMetadata); err != nil {
+		return nil, err
+	}
+
+	if err := saveCatalog(projectDir, projects); err != nil {
+		return nil, err
+	}
+
+	return &projects[idx], nil
+}
+
+// migrateCatalogMetadata moves metadata that only lives in the previous
+// catalog into sidecar files for projects that do not have one yet, matched
+// by path, so that a rescan does not discard it.
+func migrateCatalogMetadata(projects, previous []types.Project, hasSidecar map[string]bool) {
+	byPath := make(map[string]types.Project, len(previous))
+	for _, p := range previous {
+		byPath[p.Path] = p
+	}
+
+	for i := range projects {
+		if hasSidecar[projects[i].Path] {
+			continue
+		}
+		prev, ok := byPath[projects[i].Path]
+		if !ok || isEmptyMetadata(prev.ProjectMetadata) {
+			continue
+		}
+		projects[i].ProjectMetadata = prev.ProjectMetadata
+		if err := writeSidecar(projects[i].Path, prev.ProjectMetadata); err != nil {
+			log.Printf("[music-project-manager] Warning: failed to migrate metadata for %s: %v", projects[i].Path, err)
+		}
+	}
+}
+
+// resolveProjectPath returns the .RPP path for an operation that accepts either
+// a full path or a project name to look up in projects.json
+func (m *MusicProjectManagerTool) resolveProjectPath(name, projectPath string) (string, error) {
+	if projectPath == "" {
+		if name == "" {
+			return "", fmt.Errorf("either 'path' or 'name' must be provided")
+		}
+
+		settings, err := m.loadSettings()
+		if err != nil {
+			return "", fmt.Errorf("failed to load settings: %w", err)
+		}
+
+		if settings.ProjectDir == "" {
+			return "", fmt.Errorf("Music Project Manager needs to be configured. Please set project_dir in the application settings")
+		}
+
+		projects, err := loadCatalog(settings.ProjectDir)
+		if err != nil {
+			return "", err
+		}
+
+		idx, err := findProject(projects, name)
+		if err != nil {
+			return "", err
+		}
+		projectPath = projects[idx].Path
+	}
+
+	if _, err := os.Stat(projectPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("project file not found: %s", projectPath)
+	}
+
+	if strings.ToLower(filepath.Ext(projectPath)) != ".rpp" {
+		return "", fmt.Errorf("file must be a .RPP (Reaper project) file, got: %s", filepath.Ext(projectPath))
+	}
+
+	return projectPath, nil
+}
+
+// displayName returns a project's name: its file name without extension
+func displayName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// refreshCatalogEntry re-reads the size, date and tempo of a cataloged project
+// after it was edited. Projects that are not cataloged are left alone.
+func refreshCatalogEntry(projectDir, projectPath string) {
+	projects, err := loadCatalog(projectDir)
+	if err != nil {
+		return
+	}
+
+	for i := range projects {
+		if filepath.Clean(projects[i].Path) != filepath.Clean(projectPath) {
+			continue
+		}
+
+		if info, err := os.Stat(projectPath); err == nil {
+			projects[i].LastModified = info.ModTime()
+			projects[i].Size = info.Size()
+		}
+		if err := readProjectInfo(&projects[i]); err != nil {
+			log.Printf("[music-project-manager] Warning: failed to read %s: %v", projectPath, err)
+		}
+
+		if err := saveCatalog(projectDir, projects); err != nil {
+			log.Printf("[music-project-manager] Warning: failed to update projects.json: %v", err)
+		}
+		return
+	}
+}
+
+// readProjectInfo parses a project's file and records its tempo range, length
+// and the key detected from its MIDI
+func readProjectInfo(project *types.Project) error {
+	doc, err := rpp.ParseFile(project.Path)
+	if err != nil {
+		return err
+	}
+
+	node := doc.Project()
+	if node == nil {
+		return fmt.Errorf("%s is not a REAPER project", project.Path)
+	}
+
+	tm := readTempoMap(node)
+	applyTempoInfo(project, tm)
+	project.Length = roundMillis(contentEnd(node))
+
+	project.DetectedKey = ""
+	if key, ok := detectKey(readMIDITracks(node, tm)); ok {
+		project.DetectedKey = key.String()
+	}
+	return nil
+}
+
+// projectSorts lists the sort orders for list_projects and filter_project
+var projectSorts = []string{"date", "name", "bpm", "length", "rating"}
+
+// parseProjectSort validates a sort order, defaulting to date
+func parseProjectSort(sortBy string) (string, error) {
+	sortBy = strings.ToLower(strings.TrimSpace(sortBy))
+	if sortBy == "" {
+		return "date", nil
+	}
+	if !containsTag(projectSorts, sortBy) {
+		return "", fmt.Errorf("sort must be one of %s, got %q", strings.Join(projectSorts, ", "), sortBy)
+	}
+	return sortBy, nil
+}
+
+// sortProjects sorts projects by date (most recent first), name, BPM, length
+// (shortest first) or rating (highest first)
+func sortProjects(projects []types.Project, sortBy string) {
+	sort.SliceStable(projects, func(i, j int) bool {
+		a, b := projects[i], projects[j]
+		switch sortBy {
+		case "name":
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		case "bpm":
+			return a.BPM < b.BPM
+		case "length":
+			// Unknown lengths sort last
+			if (a.Length > 0) != (b.Length > 0) {
+				return a.Length > 0
+			}
+			if a.Length != b.Length {
+				return a.Length < b.Length
+			}
+		case "rating":
+			if a.Rating != b.Rating {
+				return a.Rating > b.Rating
+			}
+		}
+		return a.LastModified.After(b.LastModified)
+	})
+}
@@ -0,0 +1,253 @@
+package tool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// fxChainNames lists the chunks that hold FX plugins: track, input, master and take FX
+var fxChainNames = []string{"FXCHAIN", "FXCHAIN_REC", "MASTERFXLIST", "TAKEFX"}
+
+// fxPluginNames lists the chunk names REAPER uses for plugin instances
+var fxPluginNames = []string{"VST", "AU", "JS", "DX", "CLAP", "LV2", "VIDEO_EFFECT"}
+
+// audioExtensions lists the file types treated as rendered audio
+var audioExtensions = []string{".wav", ".flac", ".aif", ".aiff", ".mp3", ".ogg", ".opus", ".m4a", ".wv"}
+
+// trackCounts counts a project's tracks by type
+type trackCounts struct {
+	Total  int
+	Audio  int
+	MIDI   int
+	Folder int
+	Bus    int
+	Empty  int
+	Items  int
+}
+
+// countTracks classifies tracks as folders, audio or MIDI tracks by their
+// items, buses (no items, but receives) or empty tracks
+func countTracks(project *rpp.Node) trackCounts {
+	var counts trackCounts
+	for _, track := range project.ChildrenNamed("TRACK") {
+		counts.Total++
+
+		items := track.ChildrenNamed("ITEM")
+		counts.Items += len(items)
+
+		audio, midi := false, false
+		for _, item := range items {
+			if hasAudioSource(item) {
+				audio = true
+			} else if item.Child("SOURCE") != nil {
+				midi = true
+			}
+		}
+
+		switch {
+		case isFolder(track):
+			counts.Folder++
+		case audio:
+			counts.Audio++
+		case midi:
+			counts.MIDI++
+		case track.Child("AUXRECV") != nil:
+			counts.Bus++
+		default:
+			counts.Empty++
+		}
+	}
+	return counts
+}
+
+// isFolder reports whether a track starts a folder (ISBUS 1 ...)
+func isFolder(track *rpp.Node) bool {
+	bus := track.Child("ISBUS")
+	return bus != nil && bus.ParamInt(0) == 1
+}
+
+// countFX returns the number of active and total FX plugins in a project.
+// A BYPASS line before a plugin records whether it is bypassed.
+func countFX(project *rpp.Node) (int, int) {
+	active, total := 0, 0
+	project.Walk(func(n *rpp.Node) bool {
+		if !n.IsChunk() || !containsTag(fxChainNames, n.Name) {
+			return true
+		}
+
+		bypassed := false
+		for _, c := range n.Children {
+			switch {
+			case c.Name == "BYPASS":
+				bypassed = c.ParamInt(0) == 1
+			case c.IsChunk() && containsTag(fxPluginNames, c.Name):
+				total++
+				if !bypassed {
+					active++
+				}
+				bypassed = false
+			}
+		}
+		return false
+	})
+	return active, total
+}
+
+// describeTempo summarizes the tempo map: initial BPM, range, changes and time signatures
+func describeTempo(tm tempoMap) string {
+	desc := strconv.FormatFloat(tm.Initial(), 'f', -1, 64) + " BPM"
+	if lo, hi := tm.Range(); lo != hi {
+		desc += fmt.Sprintf(" (%s–%s, %d changes)", strconv.FormatFloat(lo, 'f', -1, 64), strconv.FormatFloat(hi, 'f', -1, 64), tm.Changes())
+	}
+
+	var sigs []string
+	for _, p := range tm.Points {
+		if p.Numerator == 0 {
+			continue
+		}
+		sig := fmt.Sprintf("%d/%d", p.Numerator, p.Denominator)
+		if len(sigs) == 0 || sigs[len(sigs)-1] != sig {
+			sigs = append(sigs, sig)
+		}
+	}
+	return desc + ", " + strings.Join(sigs, " → ")
+}
+
+// describeTracks formats track counts, e.g. "12 (6 audio, 3 MIDI, 2 folders, 1 bus), 48 items"
+func describeTracks(counts trackCounts) string {
+	var parts []string
+	if counts.Audio > 0 {
+		parts = append(parts, fmt.Sprintf("%d audio", counts.Audio))
+	}
+	if counts.MIDI > 0 {
+		parts = append(parts, fmt.Sprintf("%d MIDI", counts.MIDI))
+	}
+	if counts.Folder > 0 {
+		parts = append(parts, plural(counts.Folder, "folder"))
+	}
+	if counts.Bus == 1 {
+		parts = append(parts, "1 bus")
+	} else if counts.Bus > 1 {
+		parts = append(parts, fmt.Sprintf("%d buses", counts.Bus))
+	}
+	if counts.Empty > 0 {
+		parts = append(parts, fmt.Sprintf("%d empty", counts.Empty))
+	}
+
+	desc := strconv.Itoa(counts.Total)
+	if len(parts) > 0 {
+		desc += " (" + strings.Join(parts, ", ") + ")"
+	}
+	return desc + ", " + plural(counts.Items, "item")
+}
+
+// describeProject summarizes a project's arrangement: length, sections, tempo,
+// detected key, tracks by type, FX, media and last render with its measured
+// loudness, plus the plugin's own metadata
+func (m *MusicProjectManagerTool) describeProject(name, path string) (string, error) {
+	projectPath, err := m.resolveProjectPath(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return "", fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	tm := readTempoMap(project)
+	end := projectEnd(project)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", displayName(projectPath), projectPath)
+	fmt.Fprintf(&b, "Length: %s (%s)\n", formatSeconds(end), musicalLength(tm, 0, end))
+	fmt.Fprintf(&b, "Tempo: %s\n", describeTempo(tm))
+	if key, ok := detectKey(readMIDITracks(project, tm)); ok {
+		fmt.Fprintf(&b, "Detected key: %s (from MIDI)\n", key)
+	}
+
+	var sections []string
+	for _, s := range exportSections(project) {
+		label := s.Name
+		if label == "" {
+			label = "(unnamed)"
+		}
+		sections = append(sections, fmt.Sprintf("%s %s (%s)", label, formatSeconds(s.Length()), musicalLength(tm, s.Start, s.End)))
+	}
+	if len(sections) > 0 {
+		fmt.Fprintf(&b, "Sections: %s\n", strings.Join(sections, ", "))
+	} else {
+		b.WriteString("Sections: none (add regions in REAPER to label song sections)\n")
+	}
+
+	fmt.Fprintf(&b, "Tracks: %s\n", describeTracks(countTracks(project)))
+
+	active, total := countFX(project)
+	fmt.Fprintf(&b, "FX: %d active of %s\n", active, plural(total, "plugin"))
+
+	if files := referencedMedia(project, projectPath); len(files) > 0 {
+		summary := summarizeMedia(files)
+		fmt.Fprintf(&b, "Media: %s\n", summary)
+		for _, w := range mediaWarnings(summary, projectSampleRate(project)) {
+			fmt.Fprintf(&b, "Warning: %s\n", w)
+		}
+	}
+
+	renderDir := ""
+	if settings, err := m.loadSettings(); err == nil {
+		renderDir = settings.RenderDir
+	}
+	meta, _ := readSidecar(projectPath)
+	if renders := findRenders(project, projectPath, renderDir); len(renders) > 0 {
+		fmt.Fprintf(&b, "Last render: %s (%s)", filepath.Base(renders[0].Path), renders[0].ModTime.Format("2006-01-02 15:04"))
+		if info, err := os.Stat(projectPath); err == nil && renderStatus(info.ModTime(), renders) == "needs re-render" {
+			b.WriteString(", needs re-render")
+		}
+		b.WriteString("\n")
+		if meta != nil && meta.Loudness != nil && meta.Loudness.File == renders[0].Path && !meta.Loudness.AnalyzedAt.Before(renders[0].ModTime) {
+			fmt.Fprintf(&b, "Loudness: %s\n", describeLoudness(meta.Loudness))
+		}
+	} else {
+		b.WriteString("Last render: none found\n")
+	}
+
+	if meta != nil {
+		if details := describeMetadata(*meta); details != "" {
+			b.WriteString(details + "\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// describeMetadata formats the plugin metadata worth mentioning in a summary
+func describeMetadata(meta types.ProjectMetadata) string {
+	var parts []string
+	if meta.Key != "" {
+		parts = append(parts, "Key: "+meta.Key)
+	}
+	if meta.Status != "" {
+		parts = append(parts, "Status: "+meta.Status)
+	}
+	if meta.Rating > 0 {
+		parts = append(parts, "Rating: "+formatStars(meta.Rating))
+	}
+	if len(meta.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(meta.Tags, ", "))
+	}
+	if len(meta.Collaborators) > 0 {
+		parts = append(parts, "With: "+strings.Join(meta.Collaborators, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,246 @@
+package tool
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+)
+
+// markerFormats lists the file formats export_markers can write
+var markerFormats = []string{"cue", "csv", "audacity", "ffmpeg"}
+
+// markerExport is one exported section with a known end
+type markerExport struct {
+	marker
+	Position    string
+	EndPosition string
+}
+
+// exportMarkers writes a project's regions (or markers when it has no regions)
+// as a cue sheet, CSV, Audacity label track and/or FFmpeg chapter metadata next
+// to the project file
+func (m *MusicProjectManagerTool) exportMarkers(name, path, format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	formats := markerFormats
+	if format != "" && format != "all" {
+		if !containsTag(markerFormats, format) {
+			return "", fmt.Errorf("format must be one of %s or all, got %q", strings.Join(markerFormats, ", "), format)
+		}
+		formats = []string{format}
+	}
+
+	projectPath, err := m.resolveProjectPath(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return "", fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	sections := exportSections(project)
+	if len(sections) == 0 {
+		return fmt.Sprintf("%s has no markers or regions to export", displayName(projectPath)), nil
+	}
+
+	artist := ""
+	if settings, err := m.loadSettings(); err == nil {
+		artist = settings.Artist
+	}
+
+	title := displayName(projectPath)
+	base := filepath.Join(filepath.Dir(projectPath), title)
+
+	var written []string
+	for _, f := range formats {
+		var file, content string
+		switch f {
+		case "cue":
+			file, content = base+".cue", cueSheet(title, artist, sections)
+		case "csv":
+			file = base + "-markers.csv"
+			content, err = markersCSV(sections)
+			if err != nil {
+				return "", err
+			}
+		case "audacity":
+			file, content = base+"-labels.txt", audacityLabels(sections)
+		case "ffmpeg":
+			file, content = base+"-chapters.txt", ffmpegChapters(title, artist, sections)
+		}
+
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		written = append(written, file)
+	}
+
+	kind := "markers"
+	if sections[0].IsRegion {
+		kind = "regions"
+	}
+	return fmt.Sprintf("Exported %d %s from %s:\n%s", len(sections), kind, title, strings.Join(written, "\n")), nil
+}
+
+// exportSections returns the project's regions, or its markers when it has no
+// regions. Markers end where the next one starts, the last one at the project end.
+// REAPER's special markers such as =END and =START are left out.
+func exportSections(project *rpp.Node) []markerExport {
+	tm := readTempoMap(project)
+
+	var regions, markers []marker
+	for _, mk := range readMarkers(project) {
+		switch {
+		case mk.IsRegion:
+			regions = append(regions, mk)
+		case isSectionMarker(mk.Name):
+			markers = append(markers, mk)
+		}
+	}
+
+	sections := regions
+	if len(sections) == 0 {
+		end := projectEnd(project)
+		for i := range markers {
+			markers[i].End = end
+			if i+1 < len(markers) {
+				markers[i].End = markers[i+1].Start
+			}
+		}
+		sections = markers
+	}
+
+	out := make([]markerExport, len(sections))
+	for i, mk := range sections {
+		out[i] = markerExport{
+			marker:      mk,
+			Position:    tm.Position(mk.Start),
+			EndPosition: tm.Position(mk.End),
+		}
+	}
+	return out
+}
+
+// projectEnd returns the end of the project in seconds: the =END marker if
+// there is one, otherwise the end of the last item, region or marker
+func projectEnd(project *rpp.Node) float64 {
+	end := contentEnd(project)
+	for _, mk := range readMarkers(project) {
+		if mk.Name == "=END" {
+			return mk.Start
+		}
+		end = math.Max(end, mk.End)
+	}
+	return end
+}
+
+// sectionTitle returns a section's name, or a numbered placeholder for unnamed ones
+func sectionTitle(s markerExport, i int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return fmt.Sprintf("Track %02d", i+1)
+}
+
+// cueSheet formats sections as a CD cue sheet for a rendered file named after the project
+func cueSheet(title, artist string, sections []markerExport) string {
+	var b strings.Builder
+	if artist != "" {
+		fmt.Fprintf(&b, "PERFORMER %s\n", cueQuote(artist))
+	}
+	fmt.Fprintf(&b, "TITLE %s\n", cueQuote(title))
+	fmt.Fprintf(&b, "FILE %s WAVE\n", cueQuote(title+".wav"))
+	for i, s := range sections {
+		fmt.Fprintf(&b, "  TRACK %02d AUDIO\n", i+1)
+		fmt.Fprintf(&b, "    TITLE %s\n", cueQuote(sectionTitle(s, i)))
+		if artist != "" {
+			fmt.Fprintf(&b, "    PERFORMER %s\n", cueQuote(artist))
+		}
+		fmt.Fprintf(&b, "    INDEX 01 %s\n", cueTime(s.Start))
+	}
+	return b.String()
+}
+
+// cueQuote quotes a cue sheet string; cue sheets cannot escape double quotes
+func cueQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
+
+// cueTime formats seconds as mm:ss:ff with 75 frames per second
+func cueTime(seconds float64) string {
+	frames := int(math.Round(seconds * 75))
+	return fmt.Sprintf("%02d:%02d:%02d", frames/(75*60), frames/75%60, frames%75)
+}
+
+// markersCSV formats sections as CSV with times in seconds and bar.beat positions
+func markersCSV(sections []markerExport) (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+
+	rows := [][]string{{"Number", "Type", "Name", "Start", "End", "Length", "Start Position", "End Position", "Color"}}
+	for _, s := range sections {
+		kind := "marker"
+		if s.IsRegion {
+			kind = "region"
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(s.Number),
+			kind,
+			s.Name,
+			strconv.FormatFloat(s.Start, 'f', 3, 64),
+			strconv.FormatFloat(s.End, 'f', 3, 64),
+			strconv.FormatFloat(s.End-s.Start, 'f', 3, 64),
+			s.Position,
+			s.EndPosition,
+			markerColor(s.Color),
+		})
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return b.String(), nil
+}
+
+// audacityLabels formats sections as an Audacity label track (start, end and label, tab-separated)
+func audacityLabels(sections []markerExport) string {
+	var b strings.Builder
+	for i, s := range sections {
+		fmt.Fprintf(&b, "%.6f\t%.6f\t%s\n", s.Start, s.End, sectionTitle(s, i))
+	}
+	return b.String()
+}
+
+// ffmpegChapters formats sections as FFmpeg metadata chapters in milliseconds
+func ffmpegChapters(title, artist string, sections []markerExport) string {
+	var b strings.Builder
+	b.WriteString(";FFMETADATA1\n")
+	fmt.Fprintf(&b, "title=%s\n", ffmpegEscape(title))
+	if artist != "" {
+		fmt.Fprintf(&b, "artist=%s\n", ffmpegEscape(artist))
+	}
+	for i, s := range sections {
+		b.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(&b, "START=%d\n", int64(math.Round(s.Start*1000)))
+		fmt.Fprintf(&b, "END=%d\n", int64(math.Round(s.End*1000)))
+		fmt.Fprintf(&b, "title=%s\n", ffmpegEscape(sectionTitle(s, i)))
+	}
+	return b.String()
+}
+
+// ffmpegEscape escapes the characters FFmpeg metadata files treat specially
+func ffmpegEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n").Replace(s)
+}
@@ -0,0 +1,131 @@
+package tool
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/midi"
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// Krumhansl-Kessler key profiles: how well each scale degree fits a major or
+// minor key, starting from the tonic
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// minKeyNotes is the number of notes below which no key is detected
+const minKeyNotes = 8
+
+// pitchHistogram sums the length in quarter notes of every pitch class played
+// on the given tracks, and counts the notes. Drums on channel 10 are left out.
+func pitchHistogram(tracks []midiTrack) ([12]float64, int) {
+	var hist [12]float64
+	notes := 0
+
+	type noteKey struct{ channel, key byte }
+	for _, mt := range tracks {
+		events := append([]midi.Event(nil), mt.Events...)
+		sort.SliceStable(events, func(i, j int) bool { return events[i].Tick < events[j].Tick })
+
+		started := make(map[noteKey]int)
+		for _, ev := range events {
+			if len(ev.Data) != 3 || ev.Data[0]&0x0F == 9 {
+				continue
+			}
+			k := noteKey{ev.Data[0] & 0x0F, ev.Data[1]}
+			switch status := ev.Data[0] & 0xF0; {
+			case status == 0x90 && ev.Data[2] > 0:
+				started[k] = ev.Tick
+				notes++
+			case status == 0x80 || status == 0x90:
+				if start, ok := started[k]; ok {
+					hist[k.key%12] += float64(ev.Tick-start) / midiDivision
+					delete(started, k)
+				}
+			}
+		}
+	}
+	return hist, notes
+}
+
+// detectKey estimates the key of the given MIDI tracks with the
+// Krumhansl-Schmuckler algorithm: the pitch class histogram is correlated with
+// the major and minor profile of all 12 tonics and the best match wins. It
+// returns false when there are too few notes to tell.
+func detectKey(tracks []midiTrack) (musicalKey, bool) {
+	hist, notes := pitchHistogram(tracks)
+	if notes < minKeyNotes {
+		return musicalKey{}, false
+	}
+
+	best, bestScore := musicalKey{}, math.Inf(-1)
+	for tonic := 0; tonic < 12; tonic++ {
+		for _, minor := range []bool{false, true} {
+			profile := majorProfile
+			if minor {
+				profile = minorProfile
+			}
+			var rotated [12]float64
+			for i := range rotated {
+				rotated[i] = hist[(tonic+i)%12]
+			}
+			if score := correlation(rotated, profile); score > bestScore {
+				best, bestScore = musicalKey{Tonic: tonic, Minor: minor}, score
+			}
+		}
+	}
+
+	// A histogram with a single pitch class correlates with nothing
+	if math.IsInf(bestScore, -1) {
+		return musicalKey{}, false
+	}
+	return best, true
+}
+
+// correlation returns the Pearson correlation of two 12-bin distributions
+func correlation(a, b [12]float64) float64 {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i] / 12
+		meanB += b[i] / 12
+	}
+
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// projectKey returns a project's key: the one stored with set_metadata, or
+// else the key detected from its MIDI
+func projectKey(p types.Project) string {
+	if p.Key != "" {
+		return p.Key
+	}
+	return p.DetectedKey
+}
+
+// matchesKey reports whether a project is in the given key. Enharmonic
+// spellings match (F# minor and Gb minor); keys that cannot be parsed are
+// compared as text.
+func matchesKey(p types.Project, want musicalKey) bool {
+	key := projectKey(p)
+	if key == "" {
+		return false
+	}
+	k, err := parseKey(key)
+	if err != nil {
+		return strings.EqualFold(key, want.String())
+	}
+	return k == want
+}
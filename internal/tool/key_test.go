@@ -0,0 +1,153 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/midi"
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// noteTrack returns a track playing the given MIDI notes one after another
+// on a channel, each lasting beats quarter notes
+func noteTrack(channel byte, beats float64, notes ...byte) midiTrack {
+	var mt midiTrack
+	tick := 0
+	length := int(beats * midiDivision)
+	for _, note := range notes {
+		mt.Events = append(mt.Events,
+			midi.Event{Tick: tick, Data: []byte{0x90 | channel, note, 100}},
+			midi.Event{Tick: tick + length, Data: []byte{0x80 | channel, note, 0}},
+		)
+		tick += length
+		mt.Notes++
+	}
+	return mt
+}
+
+func TestDetectKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		tracks []midiTrack
+		want   string
+	}{
+		{"C major scale and triad", []midiTrack{
+			noteTrack(0, 1, 60, 62, 64, 65, 67, 69, 71, 72),
+			noteTrack(1, 4, 48, 52, 55, 48),
+		}, "C major"},
+		{"A harmonic minor", []midiTrack{
+			noteTrack(0, 1, 69, 71, 72, 74, 76, 77, 80, 81),
+			noteTrack(1, 4, 45, 48, 52, 45),
+		}, "A minor"},
+		{"F# minor", []midiTrack{
+			noteTrack(0, 1, 66, 68, 69, 71, 73, 74, 76, 78),
+			noteTrack(1, 4, 42, 45, 49, 42),
+		}, "F# minor"},
+		{"Eb major", []midiTrack{
+			noteTrack(0, 1, 63, 65, 67, 68, 70, 72, 74, 75),
+			noteTrack(1, 4, 51, 55, 58, 51),
+		}, "Eb major"},
+		// Drums on channel 10 say nothing about the key
+		{"drums left out", []midiTrack{
+			noteTrack(0, 1, 60, 62, 64, 65, 67, 69, 71, 72),
+			noteTrack(1, 4, 48, 52, 55, 48),
+			noteTrack(9, 8, 37, 37, 37, 37, 37, 37, 39, 39, 39),
+		}, "C major"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := detectKey(tt.tracks)
+			if !ok {
+				t.Fatal("no key detected")
+			}
+			if key.String() != tt.want {
+				t.Errorf("detected %s, want %s", key, tt.want)
+			}
+		})
+	}
+
+	// Too few notes to tell, and drums only
+	for _, tracks := range [][]midiTrack{
+		{noteTrack(0, 1, 60, 64, 67, 72, 76, 79, 84)},
+		{noteTrack(9, 1, 36, 38, 42, 36, 38, 42, 36, 38, 42)},
+	} {
+		if key, ok := detectKey(tracks); ok {
+			t.Errorf("detected %s from %d notes", key, tracks[0].Notes)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"C", "C major"},
+		{"c major", "C major"},
+		{"Am", "A minor"},
+		{"a min", "A minor"},
+		{"A aeolian", "A minor"},
+		{"F# minor", "F# minor"},
+		{"f#m", "F# minor"},
+		{"Gb minor", "F# minor"},
+		{"F♯ minor", "F# minor"},
+		{"Eb", "Eb major"},
+		{"D# major", "Eb major"},
+		{"E♭maj", "Eb major"},
+		{"G#m", "G# minor"},
+		{"Abm", "G# minor"},
+		{"Cb", "B major"},
+		{"B#", "C major"},
+		{" Db ionian ", "Db major"},
+	}
+	for _, tt := range tests {
+		k, err := parseKey(tt.in)
+		if err != nil {
+			t.Errorf("parseKey(%q): %v", tt.in, err)
+			continue
+		}
+		if got := k.String(); got != tt.want {
+			t.Errorf("parseKey(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "  ", "H minor", "Qb blorp", "C dorian", "minor", "F#mm"} {
+		if k, err := parseKey(in); err == nil {
+			t.Errorf("parseKey(%q) = %s, want an error", in, k)
+		}
+	}
+}
+
+func TestKeyStringRoundTrip(t *testing.T) {
+	// Every key's name parses back to the same key
+	for tonic := 0; tonic < 12; tonic++ {
+		for _, minor := range []bool{false, true} {
+			k := musicalKey{Tonic: tonic, Minor: minor}
+			got, err := parseKey(k.String())
+			if err != nil || got != k {
+				t.Errorf("parseKey(%q) = %+v, %v", k.String(), got, err)
+			}
+		}
+	}
+}
+
+func TestMatchesKey(t *testing.T) {
+	want := musicalKey{Tonic: 6, Minor: true}
+	tests := []struct {
+		project types.Project
+		match   bool
+	}{
+		{types.Project{DetectedKey: "F# minor"}, true},
+		{types.Project{ProjectMetadata: types.ProjectMetadata{Key: "Gbm"}}, true},
+		// A key set with set_metadata wins over the detected one
+		{types.Project{DetectedKey: "F# minor", ProjectMetadata: types.ProjectMetadata{Key: "A major"}}, false},
+		{types.Project{DetectedKey: "F# major"}, false},
+		{types.Project{}, false},
+		{types.Project{ProjectMetadata: types.ProjectMetadata{Key: "f# MINOR"}}, true},
+		{types.Project{ProjectMetadata: types.ProjectMetadata{Key: "sad"}}, false},
+	}
+	for _, tt := range tests {
+		if got := matchesKey(tt.project, want); got != tt.match {
+			t.Errorf("matchesKey(%q/%q) = %v, want %v", tt.project.Key, tt.project.DetectedKey, got, tt.match)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package tool
+
+import (
+	"math"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// contentEnd returns where a project's content ends in seconds: the end of
+// its last item or region, whichever is later
+func contentEnd(project *rpp.Node) float64 {
+	end := 0.0
+	for _, mk := range readMarkers(project) {
+		if mk.IsRegion {
+			end = math.Max(end, mk.End)
+		}
+	}
+
+	project.Walk(func(n *rpp.Node) bool {
+		if n.IsChunk() && n.Name == "ITEM" {
+			if pos, length := n.Child("POSITION"), n.Child("LENGTH"); pos != nil && length != nil {
+				end = math.Max(end, pos.ParamFloat(0)+length.ParamFloat(0))
+			}
+			return false
+		}
+		return true
+	})
+
+	return end
+}
+
+// matchesLength reports whether a project passes the min_length and max_length
+// filters (in seconds). A length of 0 means unknown (empty or unreadable
+// projects, or catalogs written before lengths were recorded), so such
+// projects never pass a length filter.
+func matchesLength(p types.Project, min, max int) bool {
+	if (min > 0 || max > 0) && p.Length <= 0 {
+		return false
+	}
+	if min > 0 && p.Length < float64(min) {
+		return false
+	}
+	if max > 0 && p.Length > float64(max) {
+		return false
+	}
+	return true
+}
+
+// formatLength formats a project length for tables, or "" when unknown
+func formatLength(seconds float64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return formatSeconds(seconds)
+}
@@ -0,0 +1,211 @@
+package tool
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// marker is a project marker or region. Regions are stored as two MARKER
+// lines with the same number: the start with the name, then the end.
+type marker struct {
+	Number   int
+	Name     string
+	Start    float64
+	End      float64
+	IsRegion bool
+	Color    int
+}
+
+// Length returns a region's length in seconds (0 for markers)
+func (mk marker) Length() float64 {
+	if !mk.IsRegion {
+		return 0
+	}
+	return mk.End - mk.Start
+}
+
+// readMarkers reads the markers and regions of a parsed project, sorted by start
+func readMarkers(project *rpp.Node) []marker {
+	var markers []marker
+	open := make(map[int]int)
+
+	for _, c := range project.ChildrenNamed("MARKER") {
+		number := c.ParamInt(0)
+		position := c.ParamFloat(1)
+		isRegion := c.ParamInt(3)&1 != 0
+
+		// The second line of a region holds its end
+		if isRegion {
+			if i, ok := open[number]; ok {
+				markers[i].End = position
+				delete(open, number)
+				continue
+			}
+		}
+
+		mk := marker{
+			Number:   number,
+			Name:     c.Param(2),
+			Start:    position,
+			End:      position,
+			IsRegion: isRegion,
+			Color:    c.ParamInt(4),
+		}
+		if isRegion {
+			open[number] = len(markers)
+		}
+		markers = append(markers, mk)
+	}
+
+	sort.SliceStable(markers, func(i, j int) bool {
+		return markers[i].Start < markers[j].Start
+	})
+
+	return markers
+}
+
+// isSectionMarker reports whether a marker labels a song section. Names
+// starting with "=" are REAPER commands such as =END, and the key marker
+// only records the project's key.
+func isSectionMarker(name string) bool {
+	return !strings.HasPrefix(name, "=") && !isKeyMarker(name)
+}
+
+// loadMarkers parses a project file and reads its markers, regions and tempo map
+func loadMarkers(projectPath string) ([]marker, tempoMap, error) {
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return nil, tempoMap{}, err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return nil, tempoMap{}, fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	return readMarkers(project), readTempoMap(project), nil
+}
+
+// markerColor formats a REAPER marker color as #RRGGBB. REAPER stores custom
+// colors as a native color value with the 0x1000000 flag set; 0 is the default color.
+func markerColor(color int) string {
+	if color&0x1000000 == 0 {
+		return ""
+	}
+	r, g, b := color&0xFF, color>>8&0xFF, color>>16&0xFF
+	return fmt.Sprintf("#%02X%02X%02X", r, g, b)
+}
+
+// musicalLength describes the span between two times in bars and beats of the
+// time signature in effect at start
+func musicalLength(tm tempoMap, start, end float64) string {
+	num, denom := tm.Points[0].Numerator, tm.Points[0].Denominator
+	for _, p := range tm.Points[1:] {
+		if p.Time > start {
+			break
+		}
+		if p.Numerator > 0 {
+			num, denom = p.Numerator, p.Denominator
+		}
+	}
+
+	beats := (tm.QuarterNotesAt(end) - tm.QuarterNotesAt(start)) * float64(denom) / 4
+	beats = math.Round(beats*100) / 100
+	bars := int(beats) / num
+	rest := beats - float64(bars*num)
+
+	var parts []string
+	if bars > 0 {
+		parts = append(parts, plural(bars, "bar"))
+	}
+	if rest > 0 || bars == 0 {
+		if rest == 1 {
+			parts = append(parts, "1 beat")
+		} else {
+			parts = append(parts, strconv.FormatFloat(rest, 'f', -1, 64)+" beats")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// plural formats a count with a singular or plural noun
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// markerRow is one row of the list_markers result table
+type markerRow struct {
+	Number      int     `json:"number"`
+	Type        string  `json:"type"`
+	Name        string  `json:"name"`
+	Start       float64 `json:"start"`
+	Position    string  `json:"position"`
+	End         float64 `json:"end,omitempty"`
+	EndPosition string  `json:"end_position,omitempty"`
+	Length      string  `json:"length,omitempty"`
+	Color       string  `json:"color,omitempty"`
+}
+
+// listMarkers shows a project's markers and regions with times in seconds and bar.beat positions
+func (m *MusicProjectManagerTool) listMarkers(name, path string) (string, error) {
+	projectPath, err := m.resolveProjectPath(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	markers, tm, err := loadMarkers(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	if len(markers) == 0 {
+		return fmt.Sprintf("%s has no markers or regions", displayName(projectPath)), nil
+	}
+
+	regions := 0
+	rows := make([]markerRow, len(markers))
+	for i, mk := range markers {
+		row := markerRow{
+			Number:   mk.Number,
+			Type:     "marker",
+			Name:     mk.Name,
+			Start:    roundMillis(mk.Start),
+			Position: tm.Position(mk.Start),
+			Color:    markerColor(mk.Color),
+		}
+		if isKeyMarker(mk.Name) {
+			row.Type = "key"
+		}
+		if mk.IsRegion {
+			regions++
+			row.Type = "region"
+			row.End = roundMillis(mk.End)
+			row.EndPosition = tm.Position(mk.End)
+			row.Length = fmt.Sprintf("%s (%s)", formatSeconds(mk.Length()), musicalLength(tm, mk.Start, mk.End))
+		}
+		rows[i] = row
+	}
+
+	result := pluginapi.NewTableResult(
+		"Markers",
+		[]string{"Number", "Type", "Name", "Start", "Position", "End", "End Position", "Length", "Color"},
+		rows,
+	)
+	result.Description = fmt.Sprintf("%s has %s and %s. Times are in seconds, positions in bar.beat", displayName(projectPath), plural(len(markers)-regions, "marker"), plural(regions, "region"))
+
+	return result.ToJSON()
+}
+
+// roundMillis rounds a time in seconds to milliseconds
+func roundMillis(seconds float64) float64 {
+	return math.Round(seconds*1000) / 1000
+}
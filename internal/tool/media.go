@@ -0,0 +1,307 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/audio"
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// mediaFile is a file played by a project's item sources
+type mediaFile struct {
+	Path    string
+	Uses    int
+	Size    int64
+	Missing bool
+	Info    *audio.Info
+	Err     error
+}
+
+// mediaSummary totals a project's media files
+type mediaSummary struct {
+	Files       int
+	Missing     int
+	Duration    float64
+	Size        int64
+	SampleRates map[int]int
+}
+
+// referencedMedia lists the files used by a project's item sources in order of
+// first use and reads their headers. REAPER stores paths inside the project
+// folder relative to it.
+func referencedMedia(project *rpp.Node, projectPath string) []mediaFile {
+	projectDir := filepath.Dir(projectPath)
+	index := make(map[string]int)
+	var files []mediaFile
+
+	project.Walk(func(n *rpp.Node) bool {
+		if !n.IsChunk() || n.Name != "SOURCE" {
+			return true
+		}
+		line := n.Child("FILE")
+		if line == nil || line.Param(0) == "" {
+			return true
+		}
+
+		path := line.Param(0)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+		path = filepath.Clean(path)
+
+		if i, ok := index[path]; ok {
+			files[i].Uses++
+			return true
+		}
+		index[path] = len(files)
+		files = append(files, mediaFile{Path: path, Uses: 1})
+		return true
+	})
+
+	for i := range files {
+		inspectMedia(&files[i])
+	}
+	return files
+}
+
+// inspectMedia records a media file's size and, for WAV, AIFF and FLAC, its format
+func inspectMedia(f *mediaFile) {
+	stat, err := os.Stat(f.Path)
+	if err != nil {
+		f.Missing = true
+		return
+	}
+	f.Size = stat.Size()
+
+	info, err := audio.ReadFile(f.Path)
+	if err != nil {
+		// Other formats such as MP3 or video are fine, just not inspected
+		if !errors.Is(err, audio.ErrUnsupported) {
+			f.Err = err
+		}
+		return
+	}
+	f.Info = info
+}
+
+// summarizeMedia totals the files, size and audio length of a project's media
+func summarizeMedia(files []mediaFile) mediaSummary {
+	s := mediaSummary{Files: len(files), SampleRates: make(map[int]int)}
+	for _, f := range files {
+		if f.Missing {
+			s.Missing++
+			continue
+		}
+		s.Size += f.Size
+		if f.Info != nil {
+			s.Duration += f.Info.Duration()
+			s.SampleRates[f.Info.SampleRate]++
+		}
+	}
+	return s
+}
+
+// MixedRates reports whether the media uses more than one sample rate
+func (s mediaSummary) MixedRates() bool {
+	return len(s.SampleRates) > 1
+}
+
+// RatesText lists the sample rates in use, e.g. "44100 Hz (3 files), 48000 Hz (5 files)"
+func (s mediaSummary) RatesText() string {
+	rates := make([]int, 0, len(s.SampleRates))
+	for rate := range s.SampleRates {
+		rates = append(rates, rate)
+	}
+	sort.Ints(rates)
+
+	parts := make([]string, len(rates))
+	for i, rate := range rates {
+		parts[i] = fmt.Sprintf("%d Hz (%s)", rate, plural(s.SampleRates[rate], "file"))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// String summarizes the media, e.g. "14 files, 12:34.000 of audio, 1.2 GB (2 missing)"
+func (s mediaSummary) String() string {
+	desc := fmt.Sprintf("%s, %s of audio, %s", plural(s.Files, "file"), formatSeconds(s.Duration), formatSize(s.Size))
+	if s.Missing > 0 {
+		desc += fmt.Sprintf(" (%d missing)", s.Missing)
+	}
+	return desc
+}
+
+// projectSampleRate returns the sample rate a project forces, or 0 when it
+// uses the audio device's rate
+func projectSampleRate(project *rpp.Node) int {
+	line := project.Child("SAMPLERATE")
+	if line == nil || line.ParamInt(1) != 1 {
+		return 0
+	}
+	return line.ParamInt(0)
+}
+
+// mediaWarnings lists sample rate problems: media mixing rates, or media at
+// a different rate than the project
+func mediaWarnings(summary mediaSummary, projectRate int) []string {
+	var warnings []string
+	if summary.MixedRates() {
+		warnings = append(warnings, "Mixed sample rates: "+summary.RatesText())
+	}
+	if projectRate > 0 {
+		other := 0
+		for rate, n := range summary.SampleRates {
+			if rate != projectRate {
+				other += n
+			}
+		}
+		if other > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s not at the project sample rate of %d Hz", plural(other, "file"), projectRate))
+		}
+	}
+	return warnings
+}
+
+// formatSize formats a size in bytes, e.g. "1.2 GB"
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, suffix := float64(size), ""
+	for _, s := range []string{"KB", "MB", "GB", "TB"} {
+		value /= unit
+		suffix = s
+		if value < unit {
+			break
+		}
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+// mediaRow is one row of the check_media result table
+type mediaRow struct {
+	File       string `json:"file"`
+	Format     string `json:"format"`
+	SampleRate string `json:"sample_rate"`
+	BitDepth   string `json:"bit_depth"`
+	Channels   string `json:"channels"`
+	Length     string `json:"length"`
+	Size       string `json:"size"`
+	Uses       int    `json:"uses"`
+	Status     string `json:"status"`
+	Details    string `json:"details,omitempty"`
+}
+
+// checkMedia lists the media files a project uses with their format, length and
+// size, and flags missing files and sample rate mismatches
+func (m *MusicProjectManagerTool) checkMedia(name, path string) (string, error) {
+	projectPath, err := m.resolveProjectPath(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return "", fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	files := referencedMedia(project, projectPath)
+	if len(files) == 0 {
+		return fmt.Sprintf("%s does not use any media files", displayName(projectPath)), nil
+	}
+
+	projectDir := filepath.Dir(projectPath)
+	rows := make([]mediaRow, len(files))
+	for i, f := range files {
+		file := f.Path
+		if rel, err := filepath.Rel(projectDir, f.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+
+		row := mediaRow{
+			File:   file,
+			Format: strings.ToUpper(strings.TrimPrefix(filepath.Ext(f.Path), ".")),
+			Uses:   f.Uses,
+			Status: "ok",
+		}
+		switch {
+		case f.Missing:
+			row.Status = "missing"
+		case f.Err != nil:
+			row.Status = "unreadable"
+			row.Details = f.Err.Error()
+		default:
+			row.Size = formatSize(f.Size)
+		}
+
+		if info := f.Info; info != nil {
+			row.Format = info.Format
+			row.SampleRate = strconv.Itoa(info.SampleRate)
+			row.BitDepth = strconv.Itoa(info.BitDepth)
+			if info.Float {
+				row.BitDepth += " float"
+			}
+			row.Channels = strconv.Itoa(info.Channels)
+			row.Length = formatSeconds(info.Duration())
+			row.Details = mediaDetails(info)
+		}
+		rows[i] = row
+	}
+
+	summary := summarizeMedia(files)
+	desc := fmt.Sprintf("%s uses %s", displayName(projectPath), summary)
+	for _, w := range mediaWarnings(summary, projectSampleRate(project)) {
+		desc += ". Warning: " + w
+	}
+
+	result := pluginapi.NewTableResult(
+		"Project Media",
+		[]string{"File", "Format", "Sample Rate", "Bit Depth", "Channels", "Length", "Size", "Uses", "Status", "Details"},
+		rows,
+	)
+	result.Description = desc
+
+	return result.ToJSON()
+}
+
+// mediaDetails formats the Broadcast Wave and iXML fields worth showing
+func mediaDetails(info *audio.Info) string {
+	var parts []string
+	if bwf := info.BWF; bwf != nil {
+		if bwf.Description != "" {
+			parts = append(parts, bwf.Description)
+		}
+		if bwf.Originator != "" {
+			parts = append(parts, "by "+bwf.Originator)
+		}
+		if bwf.OriginationDate != "" {
+			parts = append(parts, strings.TrimSpace(bwf.OriginationDate+" "+bwf.OriginationTime))
+		}
+	}
+	if ixml := info.IXML; ixml != nil {
+		for _, field := range []struct{ label, value string }{
+			{"project", ixml.Project},
+			{"scene", ixml.Scene},
+			{"take", ixml.Take},
+			{"tape", ixml.Tape},
+			{"note", ixml.Note},
+		} {
+			if field.value != "" {
+				parts = append(parts, field.label+" "+field.value)
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,399 @@
+package tool
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/midi"
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+)
+
+// midiDivision is the resolution of exported MIDI files in ticks per quarter note
+const midiDivision = 960
+
+// midiEvent is one event of a MIDI source, positioned in quarter notes from the source start
+type midiEvent struct {
+	QN   float64
+	Data []byte
+}
+
+// midiSource is the decoded content of a <SOURCE MIDI chunk. Length is the
+// source length in quarter notes, which is where a looped item repeats.
+type midiSource struct {
+	Events []midiEvent
+	Length float64
+	Pool   string
+}
+
+// midiTrack is the MIDI of one REAPER track placed on the project timeline
+type midiTrack struct {
+	Name   string
+	Events []midi.Event
+	Notes  int
+}
+
+// readMIDISource decodes the event lines of a <SOURCE MIDI chunk. E lines hold
+// a delta in ticks and the message bytes in hex; <X chunks hold SysEx and meta
+// events in base64. Lowercase names mark selected events and an "m" suffix
+// marks muted events, which are left out.
+func readMIDISource(source *rpp.Node) midiSource {
+	src := midiSource{}
+	ppq := 960.0
+	if hasData := source.Child("HASDATA"); hasData != nil && hasData.ParamFloat(1) > 0 {
+		ppq = hasData.ParamFloat(1)
+	}
+	if pool := source.Child("POOLEDEVTS"); pool != nil {
+		src.Pool = pool.Param(0)
+	}
+
+	ticks := 0
+	for _, c := range source.Children {
+		kind := strings.ToUpper(strings.TrimSuffix(c.Name, "m"))
+		if (kind != "E" && kind != "X") || len(c.Params) == 0 {
+			continue
+		}
+		delta, err := strconv.Atoi(c.Param(0))
+		if err != nil {
+			continue
+		}
+		ticks += delta
+		if strings.HasSuffix(c.Name, "m") {
+			continue
+		}
+
+		var data []byte
+		if c.IsChunk() {
+			data = decodeMIDIPayload(c)
+		} else {
+			data = decodeMIDIBytes(c.Params[1:])
+		}
+		if len(data) > 0 {
+			src.Events = append(src.Events, midiEvent{QN: float64(ticks) / ppq, Data: data})
+		}
+	}
+	src.Length = float64(ticks) / ppq
+
+	// REAPER closes every source with an all-notes-off controller that only marks its length
+	if n := len(src.Events); n > 0 {
+		last := src.Events[n-1].Data
+		if len(last) == 3 && last[0]&0xF0 == 0xB0 && last[1] == 123 {
+			src.Events = src.Events[:n-1]
+		}
+	}
+
+	return src
+}
+
+// decodeMIDIBytes parses the hex bytes of an E line into a complete channel message
+func decodeMIDIBytes(params []string) []byte {
+	var data []byte
+	for _, p := range params {
+		b, err := strconv.ParseUint(p, 16, 8)
+		if err != nil {
+			break
+		}
+		data = append(data, byte(b))
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	n := midi.MessageLength(data[0])
+	if n == 0 || len(data) < n {
+		return nil
+	}
+	return data[:n]
+}
+
+// decodeMIDIPayload decodes the base64 body of an <X chunk. SysEx and the
+// standard text meta events are kept; REAPER's own notation events are not.
+// REAPER stores meta events as 0xFF, the type and the text, without a length.
+func decodeMIDIPayload(chunk *rpp.Node) []byte {
+	var encoded strings.Builder
+	for _, line := range chunk.Children {
+		encoded.WriteString(strings.TrimSpace(line.Text()))
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil || len(data) < 2 {
+		return nil
+	}
+
+	switch {
+	case data[0] == 0xF0:
+		return data
+	case data[0] == 0xFF && data[1] >= midi.MetaText && data[1] <= 0x07:
+		return midi.MetaEvent(data[1], data[2:])
+	}
+	return nil
+}
+
+// activeMIDISource returns the MIDI source of an item's active take, or nil.
+// Takes after the first start with a TAKE line; "TAKE SEL" marks the active one.
+func activeMIDISource(item *rpp.Node) *rpp.Node {
+	var takes [][]*rpp.Node
+	current := []*rpp.Node{}
+	active := 0
+	for _, c := range item.Children {
+		if c.Name == "TAKE" {
+			takes = append(takes, current)
+			current = []*rpp.Node{}
+			if strings.EqualFold(c.Param(0), "SEL") {
+				active = len(takes)
+			}
+			continue
+		}
+		current = append(current, c)
+	}
+	takes = append(takes, current)
+
+	for _, c := range takes[active] {
+		if c.IsChunk() && c.Name == "SOURCE" && strings.EqualFold(c.Param(0), "MIDI") {
+			return c
+		}
+	}
+	return nil
+}
+
+// readMIDITracks collects the MIDI items of every track on the project
+// timeline. Muted items are skipped and looped items repeat their source.
+func readMIDITracks(project *rpp.Node, tm tempoMap) []midiTrack {
+	// Pooled items may only carry their events in one of the copies
+	pools := make(map[string]midiSource)
+	project.Walk(func(n *rpp.Node) bool {
+		if n.IsChunk() && n.Name == "SOURCE" && strings.EqualFold(n.Param(0), "MIDI") {
+			if src := readMIDISource(n); src.Pool != "" && len(src.Events) > 0 {
+				pools[src.Pool] = src
+			}
+			return false
+		}
+		return true
+	})
+
+	var tracks []midiTrack
+	for i, track := range project.ChildrenNamed("TRACK") {
+		mt := midiTrack{Name: fmt.Sprintf("Track %d", i+1)}
+		if name := track.Child("NAME"); name != nil && name.Param(0) != "" {
+			mt.Name = name.Param(0)
+		}
+
+		for _, item := range track.ChildrenNamed("ITEM") {
+			if mute := item.Child("MUTE"); mute != nil && mute.ParamInt(0) == 1 {
+				continue
+			}
+			source := activeMIDISource(item)
+			if source == nil {
+				continue
+			}
+			src := readMIDISource(source)
+			if len(src.Events) == 0 && src.Pool != "" {
+				src = pools[src.Pool]
+			}
+			mt.Notes += placeMIDIItem(&mt, item, src, tm)
+		}
+
+		if len(mt.Events) > 0 {
+			tracks = append(tracks, mt)
+		}
+	}
+	return tracks
+}
+
+// placeMIDIItem adds an item's events to a track at their project positions and
+// returns the number of notes added. Notes are cut at the item end.
+func placeMIDIItem(mt *midiTrack, item *rpp.Node, src midiSource, tm tempoMap) int {
+	var position, length, offset float64
+	if c := item.Child("POSITION"); c != nil {
+		position = c.ParamFloat(0)
+	}
+	if c := item.Child("LENGTH"); c != nil {
+		length = c.ParamFloat(0)
+	}
+	startQN := tm.QuarterNotesAt(position)
+	endQN := tm.QuarterNotesAt(position + length)
+	if c := item.Child("SOFFS"); c != nil {
+		offset = tm.QuarterNotesAt(position+c.ParamFloat(0)) - startQN
+	}
+
+	repeats := 1
+	if loop := item.Child("LOOP"); loop != nil && loop.ParamInt(0) == 1 && src.Length > 0 {
+		repeats = int(math.Ceil((endQN - startQN + offset) / src.Length))
+	}
+
+	const epsilon = 1e-9
+	type noteKey struct{ channel, key byte }
+	sounding := make(map[noteKey]bool)
+	notes := 0
+
+	add := func(qn float64, data []byte) {
+		mt.Events = append(mt.Events, midi.Event{Tick: int(math.Round(qn * midiDivision)), Data: data})
+	}
+
+	for r := 0; r < repeats; r++ {
+		base := startQN - offset + float64(r)*src.Length
+		for _, ev := range src.Events {
+			qn := base + ev.QN
+			status := ev.Data[0] & 0xF0
+			noteOn := status == 0x90 && len(ev.Data) == 3 && ev.Data[2] > 0
+			noteOff := status == 0x80 || (status == 0x90 && len(ev.Data) == 3 && ev.Data[2] == 0)
+
+			switch {
+			case noteOff:
+				k := noteKey{ev.Data[0] & 0x0F, ev.Data[1]}
+				if !sounding[k] {
+					continue
+				}
+				delete(sounding, k)
+				add(math.Min(qn, endQN), ev.Data)
+			case qn < startQN-epsilon || qn >= endQN-epsilon:
+				continue
+			case noteOn:
+				sounding[noteKey{ev.Data[0] & 0x0F, ev.Data[1]}] = true
+				notes++
+				add(qn, ev.Data)
+			default:
+				add(qn, ev.Data)
+			}
+		}
+	}
+
+	// Release notes still sounding when the item ends, in a stable order
+	var open []noteKey
+	for k := range sounding {
+		open = append(open, k)
+	}
+	sort.Slice(open, func(i, j int) bool {
+		if open[i].channel != open[j].channel {
+			return open[i].channel < open[j].channel
+		}
+		return open[i].key < open[j].key
+	})
+	for _, k := range open {
+		add(endQN, []byte{0x80 | k.channel, k.key, 0})
+	}
+
+	return notes
+}
+
+// newMIDIFile starts a MIDI file with a conductor track holding the project's
+// tempo map, time signatures and named markers. Tempo ramps become a tempo
+// change on every quarter note so event times still line up.
+func newMIDIFile(title string, tm tempoMap, markers []marker) *midi.File {
+	f := midi.NewFile(midiDivision)
+	conductor := f.AddTrack()
+	conductor.AddTrackName(0, title)
+
+	tick := func(qn float64) int {
+		return int(math.Round(qn * midiDivision))
+	}
+
+	for i, p := range tm.Points {
+		startQN := tm.QuarterNotesAt(p.Time)
+		if p.Numerator > 0 {
+			conductor.AddTimeSignature(tick(startQN), p.Numerator, p.Denominator)
+		}
+
+		if i+1 >= len(tm.Points) || !p.Ramps() || tm.Points[i+1].BPM == p.BPM {
+			conductor.AddTempo(tick(startQN), p.BPM)
+			continue
+		}
+
+		// Average tempo of each step, so the step takes exactly as long as in REAPER
+		endQN := tm.QuarterNotesAt(tm.Points[i+1].Time)
+		for qn := startQN; qn < endQN-1e-9; {
+			next := math.Min(math.Floor(qn)+1, endQN)
+			bpm := (next - qn) * 60 / (tm.SecondsAt(next) - tm.SecondsAt(qn))
+			conductor.AddTempo(tick(qn), bpm)
+			qn = next
+		}
+	}
+
+	for _, mk := range markers {
+		if mk.Name != "" && isSectionMarker(mk.Name) {
+			conductor.AddMarker(tick(tm.QuarterNotesAt(mk.Start)), mk.Name)
+		}
+	}
+
+	return f
+}
+
+// addMIDITrack adds a named track with its events to a MIDI file
+func addMIDITrack(f *midi.File, mt midiTrack) {
+	t := f.AddTrack()
+	t.AddTrackName(0, mt.Name)
+	t.Events = append(t.Events, mt.Events...)
+}
+
+// exportMIDI writes a project's MIDI items to Standard MIDI Files next to the
+// project: one multitrack file, or one file per track with perTrack. Every file
+// carries the project's tempo map, time signatures and markers.
+func (m *MusicProjectManagerTool) exportMIDI(name, path string, perTrack bool) (string, error) {
+	projectPath, err := m.resolveProjectPath(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return "", fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	title := displayName(projectPath)
+	tm := readTempoMap(project)
+	tracks := readMIDITracks(project, tm)
+	if len(tracks) == 0 {
+		return fmt.Sprintf("%s has no MIDI items to export", title), nil
+	}
+	markers := readMarkers(project)
+
+	base := filepath.Join(filepath.Dir(projectPath), title)
+	notes := 0
+	var written []string
+
+	if perTrack {
+		used := make(map[string]int)
+		for _, mt := range tracks {
+			notes += mt.Notes
+
+			fileName := strings.Map(func(r rune) rune {
+				if strings.ContainsRune(`<>:"/\|?*`, r) {
+					return '_'
+				}
+				return r
+			}, mt.Name)
+			used[fileName]++
+			if n := used[fileName]; n > 1 {
+				fileName = fmt.Sprintf("%s (%d)", fileName, n)
+			}
+
+			f := newMIDIFile(title, tm, markers)
+			addMIDITrack(f, mt)
+			file := base + "-" + fileName + ".mid"
+			if err := f.WriteFile(file); err != nil {
+				return "", fmt.Errorf("failed to write %s: %w", file, err)
+			}
+			written = append(written, file)
+		}
+	} else {
+		f := newMIDIFile(title, tm, markers)
+		for _, mt := range tracks {
+			notes += mt.Notes
+			addMIDITrack(f, mt)
+		}
+		file := base + ".mid"
+		if err := f.WriteFile(file); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		written = append(written, file)
+	}
+
+	return fmt.Sprintf("Exported %s (%s) from %s:\n%s", plural(len(tracks), "MIDI track"), plural(notes, "note"), title, strings.Join(written, "\n")), nil
+}
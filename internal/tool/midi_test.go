@@ -0,0 +1,34 @@
+package tool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/midi"
+)
+
+func TestNewMIDIFileMarkers(t *testing.T) {
+	tm := tempoMap{Points: []tempoPoint{{BPM: 120, Shape: 1, Numerator: 4, Denominator: 4}}}
+	markers := []marker{
+		{Number: 1, Name: "Key: F# minor", Start: 0},
+		{Number: 2, Name: "Intro", Start: 0},
+		{Number: 3, Name: "", Start: 4},
+		{Number: 4, Name: "Verse", Start: 8},
+		{Number: 5, Name: "=END", Start: 64},
+	}
+
+	// Only song sections become marker events, like in list_markers and export_markers
+	var got []midi.Event
+	for _, e := range newMIDIFile("Song", tm, markers).Tracks[0].Events {
+		if len(e.Data) > 1 && e.Data[0] == 0xFF && e.Data[1] == midi.MetaMarker {
+			got = append(got, e)
+		}
+	}
+	want := []midi.Event{
+		{Tick: 0, Data: midi.MetaEvent(midi.MetaMarker, []byte("Intro"))},
+		{Tick: 16 * midiDivision, Data: midi.MetaEvent(midi.MetaMarker, []byte("Verse"))},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("marker events = %v, want %v", got, want)
+	}
+}
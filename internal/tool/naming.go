@@ -0,0 +1,138 @@
+package tool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// conflictModes lists what create_project can do when the project folder already exists
+var conflictModes = []string{"error", "suffix", "open_existing"}
+
+// namingToken matches {name}, {date}, {bpm}, {key} and {n} / {n:3} tokens in a naming scheme
+var namingToken = regexp.MustCompile(`\{(name|date|bpm|key|n)(?::(\d+))?\}`)
+
+// namingValues holds the values available to a naming scheme
+type namingValues struct {
+	Name string
+	BPM  float64
+	Key  string
+}
+
+// buildProjectName applies a naming scheme such as "{date}_{name}_{bpm}bpm" or
+// "Idea {n:3}" and returns the project name. {n} is replaced with the next
+// free counter among the folders in projectDir that match the scheme.
+func buildProjectName(scheme, projectDir string, values namingValues) (string, error) {
+	scheme = strings.TrimSpace(scheme)
+	if scheme == "" {
+		return values.Name, nil
+	}
+
+	var missing []string
+	hasCounter := false
+	counterWidth := 0
+
+	resolved := namingToken.ReplaceAllStringFunc(scheme, func(token string) string {
+		m := namingToken.FindStringSubmatch(token)
+		switch m[1] {
+		case "name":
+			if values.Name == "" {
+				missing = append(missing, "name")
+			}
+			return values.Name
+		case "date":
+			return time.Now().Format("2006-01-02")
+		case "bpm":
+			if values.BPM <= 0 {
+				missing = append(missing, "bpm")
+				return ""
+			}
+			return strconv.FormatFloat(values.BPM, 'f', -1, 64)
+		case "key":
+			if values.Key == "" {
+				missing = append(missing, "key")
+			}
+			return strings.ReplaceAll(values.Key, " ", "")
+		default:
+			hasCounter = true
+			if w, err := strconv.Atoi(m[2]); err == nil {
+				counterWidth = w
+			}
+			// Keep the counter token for the second pass
+			return token
+		}
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("naming scheme %q needs %s. Please provide it", scheme, strings.Join(missing, " and "))
+	}
+
+	if !hasCounter {
+		return resolved, nil
+	}
+
+	next, err := nextProjectCounter(projectDir, resolved)
+	if err != nil {
+		return "", err
+	}
+
+	return namingToken.ReplaceAllStringFunc(resolved, func(string) string {
+		return fmt.Sprintf("%0*d", counterWidth, next)
+	}), nil
+}
+
+// nextProjectCounter returns one more than the highest counter used by the
+// folders in projectDir whose names match pattern ({n} tokens mark the counter)
+func nextProjectCounter(projectDir, pattern string) (int, error) {
+	parts := namingToken.Split(pattern, -1)
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	matcher, err := regexp.Compile(`(?i)^` + strings.Join(parts, `(\d+)`) + `$`)
+	if err != nil {
+		return 0, fmt.Errorf("invalid naming scheme: %w", err)
+	}
+
+	entries, err := os.ReadDir(projectDir)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read project directory %s: %w", projectDir, err)
+	}
+
+	highest := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		m := matcher.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		for _, digits := range m[1:] {
+			if n, err := strconv.Atoi(digits); err == nil && n > highest {
+				highest = n
+			}
+		}
+	}
+
+	return highest + 1, nil
+}
+
+// namingUsesName reports whether a naming scheme needs a project name
+func namingUsesName(scheme string) bool {
+	return scheme == "" || strings.Contains(scheme, "{name}")
+}
+
+// uniqueProjectName returns name with the first free " (2)", " (3)", ...
+// suffix for which no folder exists in projectDir
+func uniqueProjectName(projectDir, name string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if _, err := os.Stat(filepath.Join(projectDir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
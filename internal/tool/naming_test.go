@@ -0,0 +1,78 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildProjectName(t *testing.T) {
+	dir := t.TempDir()
+	for _, folder := range []string{"Idea 007", "idea 012", "Idea 3 copy", "Beat (v2)+ #4", "Beat v2 #9", "Beat (v2)+ #x"} {
+		if err := os.Mkdir(filepath.Join(dir, folder), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Files do not take a counter
+	writeTestFile(t, filepath.Join(dir, "Idea 099"), "")
+
+	today := time.Now().Format("2006-01-02")
+	tests := []struct {
+		name   string
+		scheme string
+		dir    string
+		values namingValues
+		want   string
+		err    string
+	}{
+		{"no scheme", "", dir, namingValues{Name: "Mash"}, "Mash", ""},
+		{"date, name and bpm", "{date}_{name}_{bpm}bpm", dir, namingValues{Name: "Mash", BPM: 140}, today + "_Mash_140bpm", ""},
+		{"fractional bpm", "{name} {bpm}", dir, namingValues{Name: "Mash", BPM: 92.5}, "Mash 92.5", ""},
+		{"key without spaces", "{name} {key}", dir, namingValues{Name: "Mash", Key: "F# minor"}, "Mash F#minor", ""},
+		{"zero padded counter", "Idea {n:3}", dir, namingValues{}, "Idea 013", ""},
+		{"counter", "Idea {n}", dir, namingValues{}, "Idea 13", ""},
+		{"counter without matches", "Sketch {n:2}", dir, namingValues{}, "Sketch 01", ""},
+		{"counter in a missing folder", "Idea {n}", filepath.Join(dir, "missing"), namingValues{}, "Idea 1", ""},
+		{"regex characters in name", "{name} #{n}", dir, namingValues{Name: "Beat (v2)+"}, "Beat (v2)+ #5", ""},
+		{"missing bpm", "{name}_{bpm}", dir, namingValues{Name: "Mash"}, "", "needs bpm"},
+		{"missing key and bpm", "{key}-{bpm}", dir, namingValues{}, "", "needs key and bpm"},
+		{"missing name", "{name} {n}", dir, namingValues{}, "", "needs name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildProjectName(tt.scheme, tt.dir, tt.values)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("buildProjectName(%q) = %q, want %q", tt.scheme, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextProjectCounter(t *testing.T) {
+	dir := t.TempDir()
+	for _, folder := range []string{"2026-10-01 Take 2", "2026-10-02 take 10", "2026-10-02 Take 10 (old)", "Take 99"} {
+		if err := os.Mkdir(filepath.Join(dir, folder), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only folders matching the whole pattern count, ignoring case
+	got, err := nextProjectCounter(dir, "2026-10-02 Take {n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 11 {
+		t.Errorf("nextProjectCounter = %d, want 11", got)
+	}
+}
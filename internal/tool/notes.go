@@ -0,0 +1,202 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// getNotes returns the project notes stored in the RPP <NOTES chunk
+func (m *MusicProjectManagerTool) getNotes(name, projectPath string) (string, error) {
+	projectPath, err := m.resolveProjectPath(name, projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse project file: %w", err)
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return "", fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	notes := readNotes(project)
+	if notes == "" {
+		return fmt.Sprintf("No notes in %s", projectPath), nil
+	}
+
+	return fmt.Sprintf("Notes for %s:\n%s", projectPath, notes), nil
+}
+
+// setNotes replaces or appends to the project notes in the RPP <NOTES chunk.
+// Empty notes are rejected unless clear is set, so a call without text cannot
+// wipe the notes by accident. Replaced notes are included in the result.
+func (m *MusicProjectManagerTool) setNotes(name, projectPath, notes string, appendNotes, clear bool) (string, error) {
+	switch {
+	case clear && notes != "":
+		return "", fmt.Errorf("use either notes or clear, not both")
+	case clear && appendNotes:
+		return "", fmt.Errorf("clear only works with set_notes")
+	case notes == "" && !clear:
+		return "", fmt.Errorf("notes text is required. To remove the notes, use set_notes with clear: true")
+	}
+
+	projectPath, err := m.resolveProjectPath(name, projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse project file: %w", err)
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return "", fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	existing := readNotes(project)
+	if appendNotes && existing != "" {
+		notes = existing + "\n" + notes
+	}
+	writeNotes(project, notes)
+
+	if err := doc.WriteFile(projectPath); err != nil {
+		return "", fmt.Errorf("failed to write project file: %w", err)
+	}
+
+	var msg string
+	switch {
+	case appendNotes:
+		return fmt.Sprintf("Appended notes to %s. Reload the project in REAPER if it is open.", projectPath), nil
+	case clear:
+		msg = fmt.Sprintf("Cleared notes in %s. Reload the project in REAPER if it is open.", projectPath)
+	default:
+		msg = fmt.Sprintf("Updated notes in %s. Reload the project in REAPER if it is open.", projectPath)
+	}
+	if existing != "" {
+		msg += "\nReplaced notes:\n" + existing
+	}
+	return msg, nil
+}
+
+// searchNotes searches the notes of every project in projects.json
+func (m *MusicProjectManagerTool) searchNotes(query string) (string, error) {
+	if query == "" {
+		return "", fmt.Errorf("query is required")
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	projects, err := loadCatalog(settings.ProjectDir)
+	if err != nil {
+		return "", err
+	}
+
+	matches := matchNotes(projects, query)
+	if len(matches) == 0 {
+		return fmt.Sprintf("No project notes contain '%s'", query), nil
+	}
+
+	result := pluginapi.NewTableResult(
+		"Project Notes Matches",
+		[]string{"Name", "Path", "Line"},
+		matches,
+	)
+	result.Description = fmt.Sprintf("Found %d matching lines for '%s'", len(matches), query)
+
+	return result.ToJSON()
+}
+
+// noteMatch is one row of the search_notes result table
+type noteMatch struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Line string `json:"line"`
+}
+
+// matchNotes returns the notes lines of the given projects that contain
+// query, ignoring case. Projects that cannot be parsed are skipped.
+func matchNotes(projects []types.Project, query string) []noteMatch {
+	var matches []noteMatch
+	queryLower := strings.ToLower(query)
+	for _, proj := range projects {
+		doc, err := rpp.ParseFile(proj.Path)
+		if err != nil {
+			continue
+		}
+		project := doc.Project()
+		if project == nil {
+			continue
+		}
+
+		for _, line := range strings.Split(readNotes(project), "\n") {
+			if strings.Contains(strings.ToLower(line), queryLower) {
+				matches = append(matches, noteMatch{
+					Name: proj.Name,
+					Path: proj.Path,
+					Line: strings.TrimSpace(line),
+				})
+			}
+		}
+	}
+	return matches
+}
+
+// readNotes decodes the project's <NOTES chunk. Each line of the notes is
+// stored as a child line prefixed with "|".
+func readNotes(project *rpp.Node) string {
+	chunk := project.Child("NOTES")
+	if chunk == nil {
+		return ""
+	}
+
+	var lines []string
+	for _, c := range chunk.Children {
+		text := c.Text()
+		if !strings.HasPrefix(text, "|") {
+			continue
+		}
+		lines = append(lines, strings.TrimPrefix(text, "|"))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// writeNotes replaces the project's <NOTES chunk with the given text.
+// Empty text removes the chunk.
+func writeNotes(project *rpp.Node, notes string) {
+	chunk := project.Child("NOTES")
+
+	if notes == "" {
+		if chunk != nil {
+			project.RemoveChildren(func(n *rpp.Node) bool { return n == chunk })
+		}
+		return
+	}
+
+	if chunk == nil {
+		chunk = rpp.NewChunk("NOTES", "0", "2")
+		project.InsertChild(0, chunk)
+	}
+
+	notes = strings.ReplaceAll(notes, "\r\n", "\n")
+	chunk.Children = nil
+	for _, line := range strings.Split(notes, "\n") {
+		chunk.Children = append(chunk.Children, rpp.NewRawNode("|"+line))
+	}
+}
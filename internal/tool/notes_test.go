@@ -0,0 +1,200 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// notesProject has multi-line notes with a blank line and trailing spaces
+const notesProject = `<REAPER_PROJECT 0.1 "7.22/macOS-arm64" 1727785200
+  <NOTES 0 2
+    |Lyrics: "city lights"` + "  " + `
+    |
+    |TODO: vocal too loud in the bridge
+  >
+  TEMPO 120 4 4
+>
+`
+
+// emptyNotesProject has a NOTES chunk without lines
+const emptyNotesProject = `<REAPER_PROJECT 0.1 "7.22/macOS-arm64" 1727785200
+  <NOTES 0 2
+  >
+  TEMPO 120 4 4
+>
+`
+
+// readTestNotes parses a project file and returns its notes
+func readTestNotes(t *testing.T, path string) string {
+	t.Helper()
+	return readNotes(parseTestProject(t, path))
+}
+
+func TestReadWriteNotes(t *testing.T) {
+	const want = "Lyrics: \"city lights\"  \n\nTODO: vocal too loud in the bridge"
+	tests := []struct {
+		name, project, notes string
+	}{
+		{"multi-line", notesProject, want},
+		{"empty NOTES chunk", emptyNotesProject, ""},
+		{"no NOTES chunk", constantTempoProject, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readTestNotes(t, writeTestProject(t, tt.project)); got != tt.notes {
+				t.Errorf("notes = %q, want %q", got, tt.notes)
+			}
+		})
+	}
+
+	// Written notes read back the same, CRLF line breaks included
+	project := parseTestProject(t, writeTestProject(t, constantTempoProject))
+	writeNotes(project, "Verse:\r\n  keep it sparse  \r\n\r\nMix: more air")
+	if got := readNotes(project); got != "Verse:\n  keep it sparse  \n\nMix: more air" {
+		t.Errorf("notes after writing = %q", got)
+	}
+	if first := project.Children[0]; first.Name != "NOTES" || !first.IsChunk() {
+		t.Errorf("NOTES chunk not added at the start of the project, got %s", first.Name)
+	}
+
+	writeNotes(project, "")
+	if project.Child("NOTES") != nil {
+		t.Error("writing empty notes kept the NOTES chunk")
+	}
+}
+
+func TestSetNotes(t *testing.T) {
+	const old = "Lyrics: \"city lights\"  \n\nTODO: vocal too loud in the bridge"
+	tests := []struct {
+		name        string
+		project     string
+		notes       string
+		append      bool
+		clear       bool
+		want        string
+		wantMessage []string
+	}{
+		{"replace", notesProject, "Mix:\nmore air", false, false, "Mix:\nmore air", []string{"Updated notes", "Replaced notes:\n" + old}},
+		{"append", notesProject, "Mix: more air", true, false, old + "\nMix: more air", []string{"Appended notes"}},
+		{"clear", notesProject, "", false, true, "", []string{"Cleared notes", "Replaced notes:\n" + old}},
+		{"set on empty NOTES chunk", emptyNotesProject, "Idea: half time", false, false, "Idea: half time", []string{"Updated notes"}},
+		{"append to empty NOTES chunk", emptyNotesProject, "Idea: half time", true, false, "Idea: half time", []string{"Appended notes"}},
+		{"append without NOTES chunk", constantTempoProject, "Idea: half time", true, false, "Idea: half time", []string{"Appended notes"}},
+		{"clear empty NOTES chunk", emptyNotesProject, "", false, true, "", []string{"Cleared notes"}},
+	}
+
+	m := &MusicProjectManagerTool{settings: &types.Settings{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestProject(t, tt.project)
+			msg, err := m.setNotes("", path, tt.notes, tt.append, tt.clear)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readTestNotes(t, path); got != tt.want {
+				t.Errorf("notes = %q, want %q", got, tt.want)
+			}
+			for _, want := range tt.wantMessage {
+				if !strings.Contains(msg, want) {
+					t.Errorf("message %q does not contain %q", msg, want)
+				}
+			}
+			if !strings.Contains(tt.project, "|") && strings.Contains(msg, "Replaced notes") {
+				t.Errorf("message reports replaced notes for a project without notes: %q", msg)
+			}
+
+			// Only the notes change
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), "\n  TEMPO 120 4 4\n") {
+				t.Errorf("project after set_notes:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestSetNotesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		notes  string
+		append bool
+		clear  bool
+	}{
+		{"empty notes", "", false, false},
+		{"empty append", "", true, false},
+		{"notes and clear", "Mix: more air", false, true},
+		{"append and clear", "", true, true},
+	}
+
+	m := &MusicProjectManagerTool{settings: &types.Settings{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestProject(t, notesProject)
+			if _, err := m.setNotes("", path, tt.notes, tt.append, tt.clear); err == nil {
+				t.Fatal("setNotes succeeded")
+			}
+			data, _ := os.ReadFile(path)
+			if string(data) != notesProject {
+				t.Errorf("project changed:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestSearchNotes(t *testing.T) {
+	root := t.TempDir()
+	var projects []types.Project
+	for name, content := range map[string]string{
+		"Song":  notesProject,
+		"Empty": emptyNotesProject,
+		"Plain": constantTempoProject,
+	} {
+		path := filepath.Join(root, name, name+".RPP")
+		writeTestFile(t, path, content)
+		projects = append(projects, types.Project{Name: name, Path: path})
+	}
+	other := filepath.Join(root, "Other", "Other.RPP")
+	writeTestFile(t, other, strings.Replace(notesProject, "TODO: vocal too loud in the bridge", "Vocal   TOO LOUD  ", 1))
+	projects = append(projects, types.Project{Name: "Other", Path: other})
+	if err := saveCatalog(root, projects); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, match := range matchNotes(projects, "too loud") {
+		got = append(got, match.Name+": "+match.Line)
+	}
+	sort.Strings(got)
+	if want := []string{"Other: Vocal   TOO LOUD", "Song: TODO: vocal too loud in the bridge"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("matches = %q, want %q", got, want)
+	}
+
+	m := &MusicProjectManagerTool{settings: &types.Settings{ProjectDir: root}}
+	result, err := m.searchNotes("too loud")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "Found 2 matching lines for 'too loud'") {
+		t.Errorf("result = %s", result)
+	}
+
+	result, err = m.searchNotes("chorus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "No project notes contain 'chorus'" {
+		t.Errorf("result = %q", result)
+	}
+
+	if _, err := m.searchNotes(""); err == nil {
+		t.Error("searchNotes without a query succeeded")
+	}
+}
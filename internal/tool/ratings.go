@@ -0,0 +1,84 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// rateProject sets a project's star rating (1-5). A rating of 0 clears it.
+func (m *MusicProjectManagerTool) rateProject(name string, rating *int) (string, error) {
+	if rating == nil {
+		return "", fmt.Errorf("rating is required for rate_project (1-5, or 0 to clear the rating)")
+	}
+	if *rating < 0 || *rating > 5 {
+		return "", fmt.Errorf("rating must be between 1 and 5, or 0 to clear it, got %d", *rating)
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	project, err := updateProject(settings.ProjectDir, name, func(p *types.Project) error {
+		p.Rating = *rating
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if project.Rating == 0 {
+		return fmt.Sprintf("Cleared the rating of '%s'", project.Name), nil
+	}
+	return fmt.Sprintf("Rated '%s' %s (%d/5)", project.Name, formatStars(project.Rating), project.Rating), nil
+}
+
+// favoriteProject marks or unmarks a project as a favorite
+func (m *MusicProjectManagerTool) favoriteProject(name string, favorite *bool) (string, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	// Marking as favorite is the default when the flag is omitted
+	value := favorite == nil || *favorite
+
+	project, err := updateProject(settings.ProjectDir, name, func(p *types.Project) error {
+		p.Favorite = value
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if project.Favorite {
+		return fmt.Sprintf("Added '%s' to favorites", project.Name), nil
+	}
+	return fmt.Sprintf("Removed '%s' from favorites", project.Name), nil
+}
+
+// matchesRating reports whether a project passes the min_rating and favorites_only filters
+func matchesRating(p types.Project, minRating int, favoritesOnly bool) bool {
+	if minRating > 0 && p.Rating < minRating {
+		return false
+	}
+	if favoritesOnly && !p.Favorite {
+		return false
+	}
+	return true
+}
+
+// formatStars renders a rating as filled and empty stars
+func formatStars(rating int) string {
+	return strings.Repeat("★", rating) + strings.Repeat("☆", 5-rating)
+}
@@ -0,0 +1,62 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+func TestRateProject(t *testing.T) {
+	path := writeTestProject(t, constantTempoProject)
+	dir := t.TempDir()
+	if err := saveCatalog(dir, []types.Project{{Name: "Song", Path: path}}); err != nil {
+		t.Fatal(err)
+	}
+	m := &MusicProjectManagerTool{settings: &types.Settings{ProjectDir: dir}}
+
+	rating := func(n int) *int { return &n }
+	tests := []struct {
+		rating *int
+		want   int
+		msg    string
+	}{
+		{rating(4), 4, "Rated 'Song' ★★★★☆ (4/5)"},
+		{rating(0), 0, "Cleared the rating of 'Song'"},
+	}
+	for _, tt := range tests {
+		msg, err := m.rateProject("Song", tt.rating)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg != tt.msg {
+			t.Errorf("message = %q, want %q", msg, tt.msg)
+		}
+
+		projects, err := loadCatalog(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta, err := readSidecar(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if projects[0].Rating != tt.want || meta.Rating != tt.want {
+			t.Errorf("rating = %d in catalog, %d in sidecar, want %d", projects[0].Rating, meta.Rating, tt.want)
+		}
+	}
+
+	// A cleared rating is unrated for min_rating
+	projects, _ := loadCatalog(dir)
+	if matchesRating(projects[0], 1, false) {
+		t.Error("cleared rating matches min_rating 1")
+	}
+
+	for _, r := range []*int{nil, rating(-1), rating(6)} {
+		if _, err := m.rateProject("Song", r); err == nil {
+			t.Errorf("rateProject(%v) succeeded", r)
+		} else if r == nil && !strings.Contains(err.Error(), "required") {
+			t.Errorf("missing rating: %v", err)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package tool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/johnjallday/music_project_manager/internal/audio"
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// renderWildcard matches REAPER render wildcards such as $project or $date
+var renderWildcard = regexp.MustCompile(`\$[A-Za-z]+`)
+
+// renderFile is a rendered audio file found for a project
+type renderFile struct {
+	Path    string
+	ModTime time.Time
+	Size    int64
+}
+
+// renderLocation returns the folder a project renders to and the file name
+// pattern it uses. RENDER_FILE holds the folder (or a full file path) and
+// RENDER_PATTERN the name with wildcards; REAPER names renders after the
+// project when both are empty.
+func renderLocation(project *rpp.Node, projectPath string) (string, string) {
+	dir := filepath.Dir(projectPath)
+	pattern := ""
+	if line := project.Child("RENDER_PATTERN"); line != nil {
+		pattern = line.Param(0)
+	}
+
+	if line := project.Child("RENDER_FILE"); line != nil && line.Param(0) != "" {
+		file := line.Param(0)
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		if containsTag(audioExtensions, strings.ToLower(filepath.Ext(file))) {
+			dir = filepath.Dir(file)
+			if pattern == "" {
+				pattern = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+			}
+		} else {
+			dir = file
+		}
+	}
+
+	if pattern == "" {
+		pattern = "$project"
+	}
+
+	// A pattern may render into a subfolder, e.g. "Renders/$project"
+	if sub := filepath.Dir(pattern); sub != "." && !renderWildcard.MatchString(sub) {
+		dir = filepath.Join(dir, sub)
+	}
+	return dir, filepath.Base(pattern)
+}
+
+// renderMatcher turns a render file name pattern into a regular expression.
+// $project is the project name and other wildcards match any text; a
+// numbered suffix REAPER adds to avoid overwriting files is allowed.
+func renderMatcher(pattern, projectName string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	last := 0
+	for _, loc := range renderWildcard.FindAllStringIndex(pattern, -1) {
+		b.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		if strings.EqualFold(pattern[loc[0]:loc[1]], "$project") {
+			b.WriteString(regexp.QuoteMeta(projectName))
+		} else {
+			b.WriteString(".+")
+		}
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(pattern[last:]))
+	b.WriteString(`(?:[-_ ]\d+)?$`)
+	return regexp.MustCompile(b.String())
+}
+
+// namesProject reports whether a render file name pattern tells one project's
+// renders apart from another's: it uses $project or literal text such as a
+// song title. Patterns of other wildcards only, like "$track" or
+// "$region-$date", match any audio file.
+func namesProject(pattern string) bool {
+	for _, wildcard := range renderWildcard.FindAllString(pattern, -1) {
+		if strings.EqualFold(wildcard, "$project") {
+			return true
+		}
+	}
+	return strings.IndexFunc(renderWildcard.ReplaceAllString(pattern, ""), func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+// findRenders looks for a project's rendered files in its render folder and
+// in a subfolder named after the project in the configured render directory,
+// newest first. The project folder and the render directory itself are
+// shared with stems, samples and other projects' renders, so they are
+// searched only when the pattern names the project.
+func findRenders(project *rpp.Node, projectPath, renderDir string) []renderFile {
+	name := displayName(projectPath)
+	dir, pattern := renderLocation(project, projectPath)
+	matcher := renderMatcher(pattern, name)
+
+	dirs := []string{dir}
+	if namesProject(pattern) {
+		dirs = append(dirs, filepath.Dir(projectPath))
+		if renderDir != "" {
+			dirs = append(dirs, renderDir)
+		}
+	}
+	if renderDir != "" {
+		dirs = append(dirs, filepath.Join(renderDir, name))
+	}
+
+	seen := make(map[string]bool)
+	var renders []renderFile
+	for _, d := range dirs {
+		entries, err := os.ReadDir(d)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || !containsTag(audioExtensions, strings.ToLower(ext)) {
+				continue
+			}
+			if !matcher.MatchString(strings.TrimSuffix(entry.Name(), ext)) {
+				continue
+			}
+
+			path := filepath.Join(d, entry.Name())
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			renders = append(renders, renderFile{Path: path, ModTime: info.ModTime(), Size: info.Size()})
+		}
+	}
+
+	sort.Slice(renders, func(i, j int) bool {
+		return renders[i].ModTime.After(renders[j].ModTime)
+	})
+	return renders
+}
+
+// renderStatus compares a project's modification time with its latest render
+func renderStatus(modified time.Time, renders []renderFile) string {
+	switch {
+	case len(renders) == 0:
+		return "never rendered"
+	case modified.After(renders[0].ModTime):
+		return "needs re-render"
+	}
+	return "up to date"
+}
+
+// renderFormat describes a rendered file, e.g. "WAV 24-bit 48 kHz" for files
+// whose header can be read, otherwise its extension
+func renderFormat(path string) string {
+	info, err := audio.ReadFile(path)
+	if err != nil {
+		return strings.ToUpper(strings.TrimPrefix(filepath.Ext(path), "."))
+	}
+	depth := fmt.Sprintf("%d-bit", info.BitDepth)
+	if info.Float {
+		depth += " float"
+	}
+	return fmt.Sprintf("%s %s %s kHz", info.Format, depth, strconv.FormatFloat(float64(info.SampleRate)/1000, 'f', -1, 64))
+}
+
+// renderRow is one row of the list_renders result table
+type renderRow struct {
+	Name     string `json:"name"`
+	Render   string `json:"render"`
+	Date     string `json:"date"`
+	Format   string `json:"format"`
+	Renders  int    `json:"renders"`
+	Modified string `json:"modified"`
+	Status   string `json:"status"`
+}
+
+// listRenders shows the latest render of each cataloged project (optionally
+// only projects whose name contains name) and flags projects changed since
+func (m *MusicProjectManagerTool) listRenders(name string) (string, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	projects, err := loadCatalog(settings.ProjectDir)
+	if err != nil {
+		return "", err
+	}
+	sortProjects(projects, "date")
+
+	var rows []renderRow
+	counts := make(map[string]int)
+	for _, p := range projects {
+		if name != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(name)) {
+			continue
+		}
+
+		doc, err := rpp.ParseFile(p.Path)
+		if err != nil {
+			continue
+		}
+		project := doc.Project()
+		if project == nil {
+			continue
+		}
+
+		modified := p.LastModified
+		if info, err := os.Stat(p.Path); err == nil {
+			modified = info.ModTime()
+		}
+
+		renders := findRenders(project, p.Path, settings.RenderDir)
+		row := renderRow{
+			Name:     p.Name,
+			Renders:  len(renders),
+			Modified: modified.Format("2006-01-02 15:04"),
+			Status:   renderStatus(modified, renders),
+		}
+		if len(renders) > 0 {
+			row.Render = renders[0].Path
+			row.Date = renders[0].ModTime.Format("2006-01-02 15:04")
+			row.Format = renderFormat(renders[0].Path)
+		}
+		counts[row.Status]++
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		if name != "" {
+			return fmt.Sprintf("No projects found matching '%s'", name), nil
+		}
+		return "No projects found. Run 'scan' to update the project list", nil
+	}
+
+	result := pluginapi.NewTableResult(
+		"Renders",
+		[]string{"Name", "Render", "Date", "Format", "Renders", "Modified", "Status"},
+		rows,
+	)
+	result.Description = fmt.Sprintf("%s: %d up to date, %d need re-render, %d never rendered",
+		plural(len(rows), "project"), counts["up to date"], counts["needs re-render"], counts["never rendered"])
+
+	return result.ToJSON()
+}
@@ -0,0 +1,117 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNamesProject(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"$project", true},
+		{"$PROJECT-$date", true},
+		{"Renders/$project", true},
+		{"Night Drive master", true},
+		{"$track v2", true},
+		{"$track", false},
+		{"$region-$date", false},
+		{"$track_$tracknumber", false},
+	}
+	for _, tt := range tests {
+		if got := namesProject(tt.pattern); got != tt.want {
+			t.Errorf("namesProject(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMatcher(t *testing.T) {
+	tests := []struct {
+		pattern, file string
+		want          bool
+	}{
+		{"$project", "Song", true},
+		{"$project", "song", true},
+		{"$project", "Song-002", true},
+		{"$project", "Song 2", true},
+		{"$project", "Song v2", false},
+		{"$project", "Other Song", false},
+		{"$project-$date", "Song-2026-10-18", true},
+		{"$track", "Drums", true},
+		{"Master (final)", "Master (final)", true},
+		{"Master (final)", "Master final", false},
+	}
+	for _, tt := range tests {
+		if got := renderMatcher(tt.pattern, "Song").MatchString(tt.file); got != tt.want {
+			t.Errorf("renderMatcher(%q) matches %q = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestFindRenders(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		want     []string
+	}{
+		{
+			"project pattern",
+			"",
+			[]string{"Song/Song 2026.wav", "Song/Song.wav", "renders/Song-001.wav", "renders/Song/Song.flac"},
+		},
+		{
+			"track pattern in a render folder",
+			"  RENDER_FILE Renders\n  RENDER_PATTERN $track\n",
+			[]string{"Song/Renders/Drums.wav", "Song/Renders/Song.wav", "renders/Song/Song.flac"},
+		},
+		{
+			"literal pattern",
+			"  RENDER_FILE Renders\n  RENDER_PATTERN \"Song $date\"\n",
+			[]string{"Song/Song 2026.wav"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestProject(t, strings.Replace(constantTempoProject, "  TEMPO 120 4 4\n", "  TEMPO 120 4 4\n"+tt.settings, 1))
+			root := filepath.Dir(filepath.Dir(path))
+			renderDir := filepath.Join(root, "renders")
+
+			for _, file := range []string{
+				// A stem and a sample kept in the project folder
+				"Song/Vocal stem.wav",
+				"Song/Song 2026.wav",
+				"Song/Song.wav",
+				"Song/Audio/Kick.wav",
+				"Song/Renders/Drums.wav",
+				"Song/Renders/Song.wav",
+				"Song/Renders/notes.txt",
+				"renders/Song-001.wav",
+				"renders/Other Song.wav",
+				"renders/Song/Song.flac",
+			} {
+				file = filepath.Join(root, file)
+				if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var got []string
+			for _, r := range findRenders(parseTestProject(t, path), path, renderDir) {
+				rel, _ := filepath.Rel(root, r.Path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("found\n  %s\nwant\n  %s", strings.Join(got, "\n  "), strings.Join(tt.want, "\n  "))
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package tool
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// validSampleRates lists the project sample rates create_project accepts
+var validSampleRates = []int{22050, 32000, 44100, 48000, 88200, 96000, 176400, 192000}
+
+// musicalSetup holds the optional musical settings applied to a new project
+type musicalSetup struct {
+	Numerator    int
+	Denominator  int
+	SampleRate   int
+	Key          string
+	LengthBars   int
+	Grid         string
+	GridDivision float64
+}
+
+// IsEmpty reports whether no musical setting besides BPM was requested
+func (s musicalSetup) IsEmpty() bool {
+	return s.Numerator == 0 && s.SampleRate == 0 && s.Key == "" && s.LengthBars == 0 && s.GridDivision == 0
+}
+
+// parseMusicalSetup validates and converts the create_project musical parameters
+func parseMusicalSetup(params types.MusicProjectParams) (musicalSetup, error) {
+	setup := musicalSetup{
+		SampleRate: params.SampleRate,
+		LengthBars: params.LengthBars,
+	}
+
+	if strings.TrimSpace(params.Key) != "" {
+		key, err := parseKey(params.Key)
+		if err != nil {
+			return setup, err
+		}
+		setup.Key = key.String()
+	}
+
+	if params.TimeSignature != "" {
+		num, denom, err := parseTimeSignature(params.TimeSignature)
+		if err != nil {
+			return setup, err
+		}
+		setup.Numerator, setup.Denominator = num, denom
+	}
+
+	if setup.SampleRate != 0 && !containsInt(validSampleRates, setup.SampleRate) {
+		return setup, fmt.Errorf("sample rate must be one of %s, got %d", joinInts(validSampleRates), setup.SampleRate)
+	}
+
+	if setup.LengthBars != 0 && (setup.LengthBars < 1 || setup.LengthBars > 999) {
+		return setup, fmt.Errorf("length must be between 1 and 999 bars, got %d", setup.LengthBars)
+	}
+
+	if params.Grid != "" {
+		division, err := parseGridDivision(params.Grid)
+		if err != nil {
+			return setup, err
+		}
+		setup.Grid = params.Grid
+		setup.GridDivision = division
+	}
+
+	return setup, nil
+}
+
+// parseTimeSignature parses a time signature such as "6/8"
+func parseTimeSignature(sig string) (int, int, error) {
+	parts := strings.Split(strings.TrimSpace(sig), "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("time signature must look like '4/4' or '7/8', got %q", sig)
+	}
+
+	num, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || num < 1 || num > 32 {
+		return 0, 0, fmt.Errorf("time signature numerator must be between 1 and 32, got %q", parts[0])
+	}
+
+	denom, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || !containsInt([]int{1, 2, 4, 8, 16, 32}, denom) {
+		return 0, 0, fmt.Errorf("time signature denominator must be 1, 2, 4, 8, 16 or 32, got %q", parts[1])
+	}
+
+	return num, denom, nil
+}
+
+// parseGridDivision parses a grid such as "1/16", "1/8T" (triplet) or "1/4D"
+// (dotted) into a fraction of a whole note, the unit REAPER stores in GRID
+func parseGridDivision(grid string) (float64, error) {
+	g := strings.ToUpper(strings.TrimSpace(grid))
+	factor := 1.0
+	switch {
+	case strings.HasSuffix(g, "T"):
+		factor = 2.0 / 3.0
+		g = strings.TrimSuffix(g, "T")
+	case strings.HasSuffix(g, "D"):
+		factor = 1.5
+		g = strings.TrimSuffix(g, "D")
+	}
+
+	parts := strings.Split(g, "/")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("grid must look like '1/16', '1/8T' or '1/4D', got %q", grid)
+	}
+
+	num, err1 := strconv.Atoi(parts[0])
+	denom, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || num < 1 || denom < 1 || denom > 128 {
+		return 0, fmt.Errorf("grid must look like '1/16', '1/8T' or '1/4D', got %q", grid)
+	}
+
+	return float64(num) / float64(denom) * factor, nil
+}
+
+// applyMusicalSetup writes time signature, sample rate, key marker, project
+// length and grid into a project file
+func applyMusicalSetup(projectPath string, setup musicalSetup) error {
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	tempo := project.Child("TEMPO")
+	if tempo == nil {
+		tempo = rpp.NewNode("TEMPO", "120", "4", "4")
+		project.InsertChild(len(project.Children), tempo)
+	}
+
+	// Time signature lives in the TEMPO line and the first tempo envelope point
+	if setup.Numerator > 0 {
+		tempo.SetParam(1, strconv.Itoa(setup.Numerator))
+		tempo.SetParam(2, strconv.Itoa(setup.Denominator))
+
+		if env := project.Child("TEMPOENVEX"); env != nil {
+			if pt := env.Child("PT"); pt != nil && pt.ParamFloat(0) == 0 {
+				pt.SetParam(3, strconv.Itoa(encodeTimeSignature(setup.Numerator, setup.Denominator)))
+			}
+		}
+	}
+
+	if setup.SampleRate > 0 {
+		line := project.Child("SAMPLERATE")
+		if line == nil {
+			line = rpp.NewNode("SAMPLERATE", "44100", "0", "0")
+			insertAfter(project, tempo, line)
+		}
+		line.SetParam(0, strconv.Itoa(setup.SampleRate))
+		// Second field enables "project sample rate" so REAPER uses it
+		line.SetParam(1, "1")
+	}
+
+	if setup.GridDivision > 0 {
+		grid := project.Child("GRID")
+		if grid == nil {
+			grid = rpp.NewNode("GRID", "3199", "8", "0.25", "8", "1", "0", "0", "0")
+			insertAfter(project, tempo, grid)
+		}
+		grid.SetParam(2, rpp.FormatFloat(setup.GridDivision))
+	}
+
+	if setup.Key != "" {
+		addMarker(project, 0, keyMarkerPrefix+setup.Key)
+	}
+
+	// REAPER treats a marker named =END as the project end
+	if setup.LengthBars > 0 {
+		bpm := tempo.ParamFloat(0)
+		num, denom := tempo.ParamInt(1), tempo.ParamInt(2)
+		if bpm <= 0 {
+			bpm = 120
+		}
+		if num <= 0 || denom <= 0 {
+			num, denom = 4, 4
+		}
+		beatsPerBar := float64(num) * 4 / float64(denom)
+		addMarker(project, float64(setup.LengthBars)*beatsPerBar*60/bpm, "=END")
+	}
+
+	return doc.WriteFile(projectPath)
+}
+
+// keyMarkerPrefix starts the name of the marker recording a new project's key
+const keyMarkerPrefix = "Key: "
+
+// isKeyMarker reports whether a marker is the key marker added by create_project
+func isKeyMarker(name string) bool {
+	if !strings.HasPrefix(name, keyMarkerPrefix) {
+		return false
+	}
+	_, err := parseKey(strings.TrimPrefix(name, keyMarkerPrefix))
+	return err == nil
+}
+
+// Key names, spelled the way the key is usually written
+var (
+	majorKeyNames = [12]string{"C", "Db", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+	minorKeyNames = [12]string{"C", "C#", "D", "Eb", "E", "F", "F#", "G", "G#", "A", "Bb", "B"}
+)
+
+// musicalKey is a tonic pitch class (0 = C) and mode
+type musicalKey struct {
+	Tonic int
+	Minor bool
+}
+
+// String formats a key as e.g. "F# minor"
+func (k musicalKey) String() string {
+	if k.Minor {
+		return minorKeyNames[k.Tonic] + " minor"
+	}
+	return majorKeyNames[k.Tonic] + " major"
+}
+
+// parseKey reads a key name such as "F# minor", "f#m", "Gb min" or "Eb"
+// (major when no mode is given)
+func parseKey(s string) (musicalKey, error) {
+	name := strings.TrimSpace(s)
+	if name == "" {
+		return musicalKey{}, fmt.Errorf("key is empty")
+	}
+
+	tonic := strings.Index("C D EF G A B", strings.ToUpper(name[:1]))
+	if tonic < 0 {
+		return musicalKey{}, fmt.Errorf("unrecognized key %q, use e.g. 'F# minor' or 'Eb major'", s)
+	}
+
+	rest := name[1:]
+accidentals:
+	for rest != "" {
+		r, size := utf8.DecodeRuneInString(rest)
+		switch r {
+		case '#', '♯':
+			tonic++
+		case 'b', '♭':
+			tonic--
+		default:
+			break accidentals
+		}
+		rest = rest[size:]
+	}
+
+	k := musicalKey{Tonic: (tonic + 12) % 12}
+	switch strings.ToLower(strings.TrimSpace(rest)) {
+	case "", "maj", "major", "ionian":
+	case "m", "min", "minor", "aeolian":
+		k.Minor = true
+	default:
+		return musicalKey{}, fmt.Errorf("unrecognized key %q, use e.g. 'F# minor' or 'Eb major'", s)
+	}
+	return k, nil
+}
+
+// encodeTimeSignature packs a time signature the way tempo envelope points store it
+func encodeTimeSignature(num, denom int) int {
+	return denom<<16 | num
+}
+
+// addMarker appends a marker after the project's existing markers using the
+// next free marker number
+func addMarker(project *rpp.Node, position float64, name string) {
+	next := 1
+	insertAt := -1
+	for i, c := range project.Children {
+		if c.Name != "MARKER" {
+			continue
+		}
+		if n := c.ParamInt(0); n >= next {
+			next = n + 1
+		}
+		insertAt = i + 1
+	}
+
+	// Markers normally sit just before the project bay and track list
+	if insertAt < 0 {
+		insertAt = len(project.Children)
+		for i, c := range project.Children {
+			if c.IsChunk() && (c.Name == "PROJBAY" || c.Name == "TRACK" || c.Name == "EXTENSIONS") {
+				insertAt = i
+				break
+			}
+		}
+	}
+
+	marker := rpp.NewNode("MARKER", strconv.Itoa(next), rpp.FormatFloat(position), name, "0", "0")
+	project.InsertChild(insertAt, marker)
+}
+
+// insertAfter inserts node directly after anchor in parent, or at the end
+// when anchor is not a child of parent
+func insertAfter(parent, anchor, node *rpp.Node) {
+	for i, c := range parent.Children {
+		if c == anchor {
+			parent.InsertChild(i+1, node)
+			return
+		}
+	}
+	parent.InsertChild(len(parent.Children), node)
+}
+
+// describeSetup summarizes the applied musical settings for the create_project message
+func describeSetup(setup musicalSetup) string {
+	var parts []string
+	if setup.Numerator > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d", setup.Numerator, setup.Denominator))
+	}
+	if setup.Key != "" {
+		parts = append(parts, "key "+setup.Key)
+	}
+	if setup.SampleRate > 0 {
+		parts = append(parts, fmt.Sprintf("%d Hz", setup.SampleRate))
+	}
+	if setup.LengthBars > 0 {
+		parts = append(parts, fmt.Sprintf("%d bars", setup.LengthBars))
+	}
+	if setup.GridDivision > 0 {
+		parts = append(parts, "grid "+setup.Grid)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// containsInt reports whether values contains v
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// joinInts formats values as a comma-separated list
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,64 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+func TestParseMusicalSetup(t *testing.T) {
+	tests := []struct {
+		name   string
+		params types.MusicProjectParams
+		want   musicalSetup
+		err    string
+	}{
+		{"empty", types.MusicProjectParams{}, musicalSetup{}, ""},
+		{"full", types.MusicProjectParams{TimeSignature: "7/8", SampleRate: 48000, Key: "f#m", LengthBars: 64, Grid: "1/8T"},
+			musicalSetup{Numerator: 7, Denominator: 8, SampleRate: 48000, Key: "F# minor", LengthBars: 64, Grid: "1/8T", GridDivision: 1.0 / 12}, ""},
+		{"key spelled out", types.MusicProjectParams{Key: " Gb major "}, musicalSetup{Key: "F# major"}, ""},
+		{"major by default", types.MusicProjectParams{Key: "Eb"}, musicalSetup{Key: "Eb major"}, ""},
+		{"invalid key", types.MusicProjectParams{Key: "Qb blorp"}, musicalSetup{}, "unrecognized key"},
+		{"invalid mode", types.MusicProjectParams{Key: "A dorian"}, musicalSetup{}, "unrecognized key"},
+		{"invalid time signature", types.MusicProjectParams{TimeSignature: "7/9"}, musicalSetup{}, "denominator"},
+		{"invalid sample rate", types.MusicProjectParams{SampleRate: 44000}, musicalSetup{}, "sample rate"},
+		{"invalid length", types.MusicProjectParams{LengthBars: 1000}, musicalSetup{}, "length"},
+		{"invalid grid", types.MusicProjectParams{Grid: "sixteenth"}, musicalSetup{}, "grid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMusicalSetup(tt.params)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want one about %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("setup = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsKeyMarker(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Key: F# minor", true},
+		{"Key: Eb major", true},
+		{"Key: Qb blorp", false},
+		{"Key change", false},
+		{"Verse", false},
+	}
+	for _, tt := range tests {
+		if got := isKeyMarker(tt.name); got != tt.want {
+			t.Errorf("isKeyMarker(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
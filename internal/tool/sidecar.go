@@ -0,0 +1,189 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// sidecarSuffix is appended to a project's name to form its metadata file,
+// e.g. "Song v2.ori-project.json" next to "Song v2.RPP". Each .RPP gets its
+// own file so projects sharing a folder keep separate metadata.
+const sidecarSuffix = ".ori-project.json"
+
+// legacySidecarName is the folder-wide metadata file written by older versions
+const legacySidecarName = ".ori-project.json"
+
+// sidecarPath returns the sidecar file location for a project file
+func sidecarPath(projectPath string) string {
+	return strings.TrimSuffix(projectPath, filepath.Ext(projectPath)) + sidecarSuffix
+}
+
+// legacySidecarPath returns the folder-wide sidecar of older versions when
+// it can only belong to this project: the folder holds no other .RPP file
+func legacySidecarPath(projectPath string) string {
+	dir := filepath.Dir(projectPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.ToLower(filepath.Ext(entry.Name())) == ".rpp" && entry.Name() != filepath.Base(projectPath) {
+			return ""
+		}
+	}
+	return filepath.Join(dir, legacySidecarName)
+}
+
+// readSidecar reads the metadata sidecar for a project, falling back to the
+// folder-wide sidecar of older versions for a project alone in its folder.
+// It returns nil without error when the project has no sidecar yet.
+func readSidecar(projectPath string) (*types.ProjectMetadata, error) {
+	path := sidecarPath(projectPath)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if path = legacySidecarPath(projectPath); path == "" {
+			return nil, nil
+		}
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+
+	var meta types.ProjectMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+
+	return &meta, nil
+}
+
+// writeSidecar writes the metadata sidecar next to the project file. A
+// folder-wide sidecar of an older version that belonged to this project is
+// removed, since its contents now live in the project's own file.
+func writeSidecar(projectPath string, meta types.ProjectMetadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project metadata: %w", err)
+	}
+
+	path := sidecarPath(projectPath)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+
+	if legacy := legacySidecarPath(projectPath); legacy != "" {
+		if err := os.Remove(legacy); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", legacySidecarName, err)
+		}
+	}
+
+	return nil
+}
+
+// isEmptyMetadata reports whether no metadata has been recorded
+func isEmptyMetadata(meta types.ProjectMetadata) bool {
+	return reflect.DeepEqual(meta, types.ProjectMetadata{})
+}
+
+// getMetadata returns a project's metadata as JSON
+func (m *MusicProjectManagerTool) getMetadata(name string) (string, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	projects, err := loadCatalog(settings.ProjectDir)
+	if err != nil {
+		return "", err
+	}
+
+	idx, err := findProject(projects, name)
+	if err != nil {
+		return "", err
+	}
+
+	project := projects[idx]
+	if isEmptyMetadata(project.ProjectMetadata) {
+		return fmt.Sprintf("Project '%s' has no metadata yet", project.Name), nil
+	}
+
+	data, err := json.MarshalIndent(project.ProjectMetadata, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal project metadata: %w", err)
+	}
+
+	return fmt.Sprintf("Metadata for '%s':\n%s", project.Name, data), nil
+}
+
+// setMetadata updates a project's collaborators, key, notes and custom fields.
+// Only the fields present in params are changed.
+func (m *MusicProjectManagerTool) setMetadata(params types.MusicProjectParams) (string, error) {
+	if params.Collaborators == nil && params.Key == "" && params.Notes == "" && len(params.Custom) == 0 {
+		return "", fmt.Errorf("nothing to update. Provide collaborators, key, notes or custom fields")
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	var changed []string
+	project, err := updateProject(settings.ProjectDir, params.Name, func(p *types.Project) error {
+		if params.Collaborators != nil {
+			p.Collaborators = normalizeTags(params.Collaborators)
+			changed = append(changed, "collaborators")
+		}
+		if params.Key != "" {
+			p.Key = strings.TrimSpace(params.Key)
+			changed = append(changed, "key")
+		}
+		if params.Notes != "" {
+			p.Notes = params.Notes
+			changed = append(changed, "notes")
+		}
+		if len(params.Custom) > 0 {
+			if p.Custom == nil {
+				p.Custom = make(map[string]string)
+			}
+			keys := make([]string, 0, len(params.Custom))
+			for k, v := range params.Custom {
+				if v == "" {
+					delete(p.Custom, k)
+				} else {
+					p.Custom[k] = v
+				}
+				keys = append(keys, k)
+			}
+			if len(p.Custom) == 0 {
+				p.Custom = nil
+			}
+			sort.Strings(keys)
+			changed = append(changed, keys...)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Updated %s for project '%s'", strings.Join(changed, ", "), project.Name), nil
+}
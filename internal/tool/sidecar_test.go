@@ -0,0 +1,191 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// writeTestFile creates a file and its folder
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readTestSidecar reads a project's sidecar and fails the test on errors
+func readTestSidecar(t *testing.T, projectPath string) *types.ProjectMetadata {
+	t.Helper()
+	meta, err := readSidecar(projectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return meta
+}
+
+// exists reports whether a file exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestSidecarPath(t *testing.T) {
+	tests := []struct {
+		project, want string
+	}{
+		{"/music/Song/Song.RPP", "/music/Song/Song.ori-project.json"},
+		{"/music/Song/Song v2.rpp", "/music/Song/Song v2.ori-project.json"},
+		{"/music/Song/Song.v2.RPP", "/music/Song/Song.v2.ori-project.json"},
+	}
+	for _, tt := range tests {
+		if got := sidecarPath(filepath.FromSlash(tt.project)); got != filepath.FromSlash(tt.want) {
+			t.Errorf("sidecarPath(%q) = %q, want %q", tt.project, got, tt.want)
+		}
+	}
+}
+
+func TestSidecarPerProject(t *testing.T) {
+	dir := t.TempDir()
+	song := filepath.Join(dir, "Song.RPP")
+	alt := filepath.Join(dir, "Song v2.RPP")
+	writeTestFile(t, song, constantTempoProject)
+	writeTestFile(t, alt, constantTempoProject)
+
+	if meta := readTestSidecar(t, song); meta != nil {
+		t.Fatalf("sidecar before writing = %+v, want nil", meta)
+	}
+
+	songMeta := types.ProjectMetadata{Tags: []string{"genre:trap"}, Status: "mixing", Rating: 4}
+	altMeta := types.ProjectMetadata{Tags: []string{"mood:dark"}, Key: "F# minor"}
+	if err := writeSidecar(song, songMeta); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSidecar(alt, altMeta); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"Song.ori-project.json", "Song v2.ori-project.json"} {
+		if !exists(filepath.Join(dir, file)) {
+			t.Errorf("%s was not written", file)
+		}
+	}
+	if got := readTestSidecar(t, song); !reflect.DeepEqual(*got, songMeta) {
+		t.Errorf("Song metadata = %+v, want %+v", *got, songMeta)
+	}
+	if got := readTestSidecar(t, alt); !reflect.DeepEqual(*got, altMeta) {
+		t.Errorf("Song v2 metadata = %+v, want %+v", *got, altMeta)
+	}
+
+	writeTestFile(t, sidecarPath(song), "{not json")
+	if _, err := readSidecar(song); err == nil {
+		t.Error("reading a broken sidecar succeeded")
+	}
+}
+
+func TestLegacySidecar(t *testing.T) {
+	dir := t.TempDir()
+	song := filepath.Join(dir, "Song.RPP")
+	legacy := filepath.Join(dir, legacySidecarName)
+	writeTestFile(t, song, constantTempoProject)
+	writeTestFile(t, legacy, `{"tags": ["genre:trap"], "status": "writing"}`)
+
+	// A project alone in its folder reads the folder-wide file of older versions
+	meta := readTestSidecar(t, song)
+	want := types.ProjectMetadata{Tags: []string{"genre:trap"}, Status: "writing"}
+	if meta == nil || !reflect.DeepEqual(*meta, want) {
+		t.Fatalf("metadata = %+v, want %+v", meta, want)
+	}
+
+	// Writing moves it into the project's own file
+	meta.Status = "mixing"
+	if err := writeSidecar(song, *meta); err != nil {
+		t.Fatal(err)
+	}
+	if exists(legacy) {
+		t.Error("legacy sidecar was not removed")
+	}
+	want.Status = "mixing"
+	if got := readTestSidecar(t, song); !reflect.DeepEqual(*got, want) {
+		t.Errorf("metadata after writing = %+v, want %+v", *got, want)
+	}
+
+	// The project's own file wins over a legacy file
+	writeTestFile(t, legacy, `{"status": "idea"}`)
+	if got := readTestSidecar(t, song); got.Status != "mixing" {
+		t.Errorf("status = %q, want the project's own mixing", got.Status)
+	}
+}
+
+func TestLegacySidecarShared(t *testing.T) {
+	dir := t.TempDir()
+	song := filepath.Join(dir, "Song.RPP")
+	alt := filepath.Join(dir, "Song v2.RPP")
+	legacy := filepath.Join(dir, legacySidecarName)
+	writeTestFile(t, song, constantTempoProject)
+	writeTestFile(t, alt, constantTempoProject)
+	const shared = `{"tags": ["genre:trap"], "rating": 5}`
+	writeTestFile(t, legacy, shared)
+
+	// A folder-wide file cannot be told apart between two projects, so
+	// neither takes it over and it is never deleted
+	for _, p := range []string{song, alt} {
+		if meta := readTestSidecar(t, p); meta != nil {
+			t.Errorf("%s read the shared legacy sidecar: %+v", filepath.Base(p), meta)
+		}
+	}
+	if err := writeSidecar(song, types.ProjectMetadata{Status: "mixing"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(legacy)
+	if err != nil {
+		t.Fatalf("shared legacy sidecar was removed: %v", err)
+	}
+	if string(data) != shared {
+		t.Errorf("shared legacy sidecar changed: %s", data)
+	}
+	if meta := readTestSidecar(t, alt); meta != nil {
+		t.Errorf("Song v2 metadata = %+v, want nil", meta)
+	}
+}
+
+func TestRenameProjectSidecar(t *testing.T) {
+	root := t.TempDir()
+	song := filepath.Join(root, "Song", "Song.RPP")
+	writeTestFile(t, song, constantTempoProject)
+	meta := types.ProjectMetadata{Tags: []string{"genre:trap"}, Rating: 4}
+	if err := writeSidecar(song, meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveCatalog(root, []types.Project{{Name: "Song", Path: song, ProjectMetadata: meta}}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MusicProjectManagerTool{settings: &types.Settings{ProjectDir: root}}
+	if _, err := m.renameProject("Song", "Night Drive"); err != nil {
+		t.Fatal(err)
+	}
+
+	renamed := filepath.Join(root, "Night Drive", "Night Drive.RPP")
+	if exists(filepath.Join(root, "Night Drive", "Song.ori-project.json")) {
+		t.Error("sidecar kept the old project name")
+	}
+	got := readTestSidecar(t, renamed)
+	if got == nil || !reflect.DeepEqual(*got, meta) {
+		t.Errorf("metadata after rename = %+v, want %+v", got, meta)
+	}
+
+	projects, err := loadCatalog(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projects[0].Path != renamed || projects[0].Name != "Night Drive" {
+		t.Errorf("catalog entry = %s at %s", projects[0].Name, projects[0].Path)
+	}
+}
@@ -0,0 +1,169 @@
+package tool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// projectStatuses lists the lifecycle statuses in workflow order
+var projectStatuses = []string{"idea", "writing", "arranging", "mixing", "mastering", "released", "abandoned"}
+
+// setStatus moves a project to a new lifecycle status and records the transition time
+func (m *MusicProjectManagerTool) setStatus(name, status string) (string, error) {
+	status = strings.ToLower(strings.TrimSpace(status))
+	if statusOrder(status) < 0 {
+		return "", fmt.Errorf("invalid status %q. Valid statuses: %s", status, strings.Join(projectStatuses, ", "))
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	var previous string
+	var previousSince time.Time
+	project, err := updateProject(settings.ProjectDir, name, func(p *types.Project) error {
+		previous = p.Status
+		previousSince = statusSince(*p)
+		if p.Status == status {
+			return nil
+		}
+		p.Status = status
+		p.StatusHistory = append(p.StatusHistory, types.StatusChange{
+			Status:    status,
+			ChangedAt: time.Now(),
+		})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if previous == status {
+		return fmt.Sprintf("Project '%s' is already in status '%s'", project.Name, status), nil
+	}
+	if previous == "" {
+		return fmt.Sprintf("Set status of '%s' to '%s'", project.Name, status), nil
+	}
+
+	msg := fmt.Sprintf("Moved '%s' from '%s' to '%s'", project.Name, previous, status)
+	if !previousSince.IsZero() {
+		msg += fmt.Sprintf(" after %s", formatDays(time.Since(previousSince)))
+	}
+	return msg, nil
+}
+
+// statusBoard returns all projects with a status grouped by status as a table
+func (m *MusicProjectManagerTool) statusBoard() (string, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	projects, err := loadCatalog(settings.ProjectDir)
+	if err != nil {
+		return "", err
+	}
+
+	var tracked []types.Project
+	for _, proj := range projects {
+		if proj.Status != "" {
+			tracked = append(tracked, proj)
+		}
+	}
+
+	if len(tracked) == 0 {
+		return "No projects have a status yet. Use 'set_status' to start tracking them.", nil
+	}
+
+	// Group by workflow order, longest-waiting projects first within a status
+	sort.Slice(tracked, func(i, j int) bool {
+		oi, oj := statusOrder(tracked[i].Status), statusOrder(tracked[j].Status)
+		if oi != oj {
+			return oi < oj
+		}
+		return statusSince(tracked[i]).Before(statusSince(tracked[j]))
+	})
+
+	type BoardRow struct {
+		Status string  `json:"status"`
+		Name   string  `json:"name"`
+		Since  string  `json:"since"`
+		Days   int     `json:"days"`
+		BPM    float64 `json:"bpm"`
+	}
+
+	counts := make(map[string]int)
+	rows := make([]BoardRow, len(tracked))
+	for i, p := range tracked {
+		row := BoardRow{
+			Status: p.Status,
+			Name:   p.Name,
+			BPM:    p.BPM,
+		}
+		if since := statusSince(p); !since.IsZero() {
+			row.Since = since.Format("2006-01-02")
+			row.Days = int(time.Since(since).Hours() / 24)
+		}
+		rows[i] = row
+		counts[p.Status]++
+	}
+
+	var summary []string
+	for _, s := range projectStatuses {
+		if counts[s] > 0 {
+			summary = append(summary, fmt.Sprintf("%s: %d", s, counts[s]))
+		}
+	}
+
+	result := pluginapi.NewTableResult(
+		"Project Status Board",
+		[]string{"Status", "Name", "Since", "Days", "BPM"},
+		rows,
+	)
+	result.Description = strings.Join(summary, ", ")
+
+	return result.ToJSON()
+}
+
+// statusOrder returns the position of status in the workflow, or -1 if unknown
+func statusOrder(status string) int {
+	for i, s := range projectStatuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// statusSince returns when the project entered its current status
+func statusSince(p types.Project) time.Time {
+	for i := len(p.StatusHistory) - 1; i >= 0; i-- {
+		if p.StatusHistory[i].Status == p.Status {
+			return p.StatusHistory[i].ChangedAt
+		}
+	}
+	return time.Time{}
+}
+
+// formatDays formats a duration as a whole number of days
+func formatDays(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
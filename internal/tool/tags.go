@@ -0,0 +1,147 @@
+package tool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// tagProject adds tags to a project in projects.json
+func (m *MusicProjectManagerTool) tagProject(name string, tags []string) (string, error) {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return "", fmt.Errorf("at least one tag is required")
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	project, err := updateProject(settings.ProjectDir, name, func(p *types.Project) error {
+		p.Tags = normalizeTags(append(p.Tags, tags...))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Tagged project '%s': %s", project.Name, strings.Join(project.Tags, ", ")), nil
+}
+
+// untagProject removes tags from a project in projects.json
+func (m *MusicProjectManagerTool) untagProject(name string, tags []string) (string, error) {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return "", fmt.Errorf("at least one tag is required")
+	}
+
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	project, err := updateProject(settings.ProjectDir, name, func(p *types.Project) error {
+		var kept []string
+		for _, t := range p.Tags {
+			if !containsTag(tags, t) {
+				kept = append(kept, t)
+			}
+		}
+		p.Tags = kept
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(project.Tags) == 0 {
+		return fmt.Sprintf("Removed tags from project '%s' (no tags left)", project.Name), nil
+	}
+	return fmt.Sprintf("Removed tags from project '%s'. Remaining tags: %s", project.Name, strings.Join(project.Tags, ", ")), nil
+}
+
+// listTags returns every tag in use with the number of projects carrying it
+func (m *MusicProjectManagerTool) listTags() (string, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir in the application settings.", nil
+	}
+
+	projects, err := loadCatalog(settings.ProjectDir)
+	if err != nil {
+		return "", err
+	}
+
+	counts := make(map[string]int)
+	for _, proj := range projects {
+		for _, t := range proj.Tags {
+			counts[t]++
+		}
+	}
+
+	if len(counts) == 0 {
+		return "No tags found. Use 'tag_project' to tag projects.", nil
+	}
+
+	type TagCount struct {
+		Tag      string `json:"tag"`
+		Projects int    `json:"projects"`
+	}
+
+	rows := make([]TagCount, 0, len(counts))
+	for t, n := range counts {
+		rows = append(rows, TagCount{Tag: t, Projects: n})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Tag < rows[j].Tag
+	})
+
+	result := pluginapi.NewTableResult(
+		"Project Tags",
+		[]string{"Tag", "Projects"},
+		rows,
+	)
+	result.Description = fmt.Sprintf("%d tags in use", len(rows))
+
+	return result.ToJSON()
+}
+
+// normalizeTags trims tags, drops empty ones and removes case-insensitive
+// duplicates while keeping the first spelling and the original order
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if t == "" || containsTag(out, t) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// containsTag reports whether tags contains tag, ignoring case
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
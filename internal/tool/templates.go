@@ -0,0 +1,529 @@
+package tool
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// templateInfo describes a project or track template found in the template directory
+type templateInfo struct {
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	BPM         float64 `json:"bpm"`
+	Tracks      int     `json:"tracks"`
+	Description string  `json:"description"`
+	Path        string  `json:"path"`
+}
+
+// listTemplates scans the template directories for .RPP and .RTrackTemplate files
+func (m *MusicProjectManagerTool) listTemplates() (string, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.TemplateDir == "" {
+		return "Music Project Manager needs to be configured. Please set template_dir in the application settings.", nil
+	}
+
+	paths, err := findTemplateFiles(settings.TemplateDir, ".rpp")
+	if err != nil {
+		return "", fmt.Errorf("failed to scan template directory %s: %w", settings.TemplateDir, err)
+	}
+
+	trackPaths, err := findTrackTemplateFiles(settings)
+	if err != nil {
+		return "", err
+	}
+	paths = append(paths, trackPaths...)
+
+	if len(paths) == 0 {
+		return fmt.Sprintf("No templates found in %s", settings.TemplateDir), nil
+	}
+
+	templates := make([]templateInfo, 0, len(paths))
+	for _, path := range paths {
+		templates = append(templates, readTemplateInfo(path))
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Type != templates[j].Type {
+			return templates[i].Type < templates[j].Type
+		}
+		return strings.ToLower(templates[i].Name) < strings.ToLower(templates[j].Name)
+	})
+
+	result := pluginapi.NewTableResult(
+		"Templates",
+		[]string{"Name", "Type", "BPM", "Tracks", "Description", "Path"},
+		templates,
+	)
+	result.Description = fmt.Sprintf("Found %d templates in %s", len(templates), settings.TemplateDir)
+
+	return result.ToJSON()
+}
+
+// resolveTemplate returns the project template file to use for create_project.
+// template may be a path or a template name from the template directory; when
+// empty, the configured default template is used.
+func resolveTemplate(settings *types.Settings, template string) (string, error) {
+	template = strings.TrimSpace(template)
+
+	if template == "" {
+		if settings.DefaultTemplate != "" {
+			if _, err := os.Stat(settings.DefaultTemplate); err == nil {
+				return settings.DefaultTemplate, nil
+			}
+		}
+		return filepath.Join(settings.TemplateDir, "default.RPP"), nil
+	}
+
+	// A path was given directly
+	if filepath.IsAbs(template) || strings.ContainsRune(template, filepath.Separator) {
+		if strings.ToLower(filepath.Ext(template)) != ".rpp" {
+			return "", fmt.Errorf("template must be a .RPP file, got: %s", template)
+		}
+		return template, nil
+	}
+
+	paths, err := findTemplateFiles(settings.TemplateDir, ".rpp")
+	if err != nil {
+		return "", fmt.Errorf("failed to scan template directory %s: %w", settings.TemplateDir, err)
+	}
+
+	return matchTemplate(paths, template)
+}
+
+// matchTemplate finds the template whose name matches exactly (ignoring case
+// and extension) or, failing that, is the only one containing name
+func matchTemplate(paths []string, name string) (string, error) {
+	if ext := strings.ToLower(filepath.Ext(name)); ext == ".rpp" || ext == ".rtracktemplate" {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	for _, path := range paths {
+		if strings.EqualFold(templateName(path), name) {
+			return path, nil
+		}
+	}
+
+	var matches []string
+	searchLower := strings.ToLower(name)
+	for _, path := range paths {
+		if strings.Contains(strings.ToLower(templateName(path)), searchLower) {
+			matches = append(matches, path)
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	var names []string
+	for _, path := range paths {
+		names = append(names, templateName(path))
+	}
+
+	if len(matches) > 1 {
+		var matchNames []string
+		for _, path := range matches {
+			matchNames = append(matchNames, templateName(path))
+		}
+		return "", fmt.Errorf("multiple templates found matching '%s': %s. Please be more specific", name, strings.Join(matchNames, ", "))
+	}
+
+	return "", fmt.Errorf("no template found matching '%s'. Available templates: %s", name, strings.Join(names, ", "))
+}
+
+// findTemplateFiles walks dir and returns files with one of the given
+// lower-case extensions
+func findTemplateFiles(dir string, exts ...string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		for _, e := range exts {
+			if ext == e {
+				paths = append(paths, path)
+				break
+			}
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// readTemplateInfo reads name, BPM, track count and description from a template file.
+// Parse errors are reported in the description rather than failing the listing.
+func readTemplateInfo(path string) templateInfo {
+	info := templateInfo{
+		Name: templateName(path),
+		Type: "project",
+		Path: path,
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".rtracktemplate" {
+		info.Type = "track"
+	}
+
+	doc, err := rpp.ParseFile(path)
+	if err != nil {
+		info.Description = fmt.Sprintf("unreadable: %v", err)
+		return info
+	}
+
+	if info.Type == "track" {
+		tracks := doc.Chunks("TRACK")
+		info.Tracks = len(tracks)
+		info.Description = strings.Join(trackNames(tracks), ", ")
+		return info
+	}
+
+	project := doc.Project()
+	if project == nil {
+		info.Description = "not a REAPER project"
+		return info
+	}
+
+	if tempo := project.Child("TEMPO"); tempo != nil {
+		info.BPM = tempo.ParamFloat(0)
+	}
+	info.Tracks = len(project.ChildrenNamed("TRACK"))
+
+	// The first line of the project notes serves as the template description
+	notes := strings.TrimSpace(readNotes(project))
+	if i := strings.IndexByte(notes, '\n'); i >= 0 {
+		notes = strings.TrimSpace(notes[:i])
+	}
+	info.Description = notes
+
+	return info
+}
+
+// trackNames returns the NAME of each track chunk
+func trackNames(tracks []*rpp.Node) []string {
+	var names []string
+	for _, t := range tracks {
+		if n := t.Child("NAME"); n != nil && n.Param(0) != "" {
+			names = append(names, n.Param(0))
+		}
+	}
+	return names
+}
+
+// templateName returns a template's display name: its file name without extension
+func templateName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// templatePlaceholder matches {{NAME}} placeholders in template files
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_]+)\s*\}\}`)
+
+// templateVariables returns the values substituted for template placeholders
+func templateVariables(settings *types.Settings, params types.MusicProjectParams, bpm float64) map[string]string {
+	values := map[string]string{
+		"PROJECT_NAME": params.Name,
+		"DATE":         time.Now().Format("2006-01-02"),
+		"ARTIST":       settings.Artist,
+		"KEY":          params.Key,
+	}
+	if bpm > 0 {
+		values["BPM"] = strconv.FormatFloat(bpm, 'f', -1, 64)
+	}
+	return values
+}
+
+// applyTemplateVariables replaces {{NAME}} placeholders in track names, notes,
+// render paths, markers and any other line of the project file. Unknown
+// placeholders are left untouched.
+func applyTemplateVariables(projectPath string, values map[string]string) error {
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return err
+	}
+
+	replace := func(s string) string {
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(match string) string {
+			key := strings.ToUpper(templatePlaceholder.FindStringSubmatch(match)[1])
+			if v, ok := values[key]; ok {
+				return v
+			}
+			return match
+		})
+	}
+
+	changed := false
+	for _, root := range doc.Nodes {
+		root.Walk(func(n *rpp.Node) bool {
+			text := n.Text()
+			if !strings.Contains(text, "{{") {
+				return true
+			}
+			changed = true
+
+			// Notes lines are free text; everything else is re-quoted per token
+			if strings.HasPrefix(text, "|") {
+				n.SetText(replace(text))
+				return true
+			}
+			params := make([]string, len(n.Params))
+			for i, p := range n.Params {
+				params[i] = replace(p)
+			}
+			n.SetParams(params...)
+			return true
+		})
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return doc.WriteFile(projectPath)
+}
+
+// saveAsTemplate writes a copy of a cataloged project into the template
+// directory with its media items, render paths and notes removed. Tracks,
+// FX chains, routing, sends and markers are kept as configured.
+func (m *MusicProjectManagerTool) saveAsTemplate(name, template, description string) (string, error) {
+	settings, err := m.loadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.ProjectDir == "" || settings.TemplateDir == "" {
+		return "Music Project Manager needs to be configured. Please set project_dir and template_dir in the application settings.", nil
+	}
+
+	projectPath, err := m.resolveProjectPath(name, "")
+	if err != nil {
+		return "", err
+	}
+
+	template = strings.TrimSpace(template)
+	if template == "" {
+		template = templateName(projectPath)
+	}
+	if strings.ContainsAny(template, `<>:"/\|?*`) {
+		return "", fmt.Errorf("template name contains invalid characters. Avoid: < > : \" / \\ | ? *")
+	}
+
+	dest := filepath.Join(settings.TemplateDir, template+".RPP")
+	if _, err := os.Stat(dest); err == nil {
+		return "", fmt.Errorf("a template named '%s' already exists at %s", template, dest)
+	}
+
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse project file: %w", err)
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return "", fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	// Strip media items from every track
+	removedItems := 0
+	tracks := project.ChildrenNamed("TRACK")
+	for _, track := range tracks {
+		removedItems += track.RemoveChildren(func(n *rpp.Node) bool {
+			return n.IsChunk() && n.Name == "ITEM"
+		})
+	}
+
+	// Clear render targets so renders from the template do not overwrite the original's
+	for _, key := range []string{"RENDER_FILE", "RENDER_PATTERN"} {
+		if line := project.Child(key); line != nil {
+			line.SetParams("")
+		}
+	}
+
+	// Replace the project notes with the template description
+	writeNotes(project, strings.TrimSpace(description))
+
+	if err := os.MkdirAll(settings.TemplateDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create template directory %q: %w", settings.TemplateDir, err)
+	}
+
+	if err := doc.WriteFile(dest); err != nil {
+		return "", fmt.Errorf("failed to write template file: %w", err)
+	}
+
+	log.Printf("[music-project-manager] Saved template '%s' from %s", template, projectPath)
+	return fmt.Sprintf("Saved template '%s' to %s (%d tracks, %d media items removed)", template, dest, len(tracks), removedItems), nil
+}
+
+// guidPattern matches REAPER GUIDs such as {0F3A...}
+var guidPattern = regexp.MustCompile(`\{[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}\}`)
+
+// resolveTrackTemplates returns the .RTrackTemplate files for the given names or paths
+func resolveTrackTemplates(settings *types.Settings, names []string) ([]string, error) {
+	var available []string
+	var paths []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if filepath.IsAbs(name) || strings.ContainsRune(name, filepath.Separator) {
+			if strings.ToLower(filepath.Ext(name)) != ".rtracktemplate" {
+				return nil, fmt.Errorf("track template must be a .RTrackTemplate file, got: %s", name)
+			}
+			paths = append(paths, name)
+			continue
+		}
+
+		if available == nil {
+			found, err := findTrackTemplateFiles(settings)
+			if err != nil {
+				return nil, err
+			}
+			available = found
+		}
+
+		path, err := matchTemplate(available, name)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// findTrackTemplateFiles lists .RTrackTemplate files in the track template
+// directory and the project template directory
+func findTrackTemplateFiles(settings *types.Settings) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, dir := range []string{settings.TrackTemplateDir, settings.TemplateDir} {
+		if dir == "" {
+			continue
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		found, err := findTemplateFiles(dir, ".rtracktemplate")
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan track template directory %s: %w", dir, err)
+		}
+		for _, path := range found {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// appendTrackTemplates appends the tracks of each track template to the end
+// of the project's track list. GUIDs are regenerated so a template can be
+// added more than once without clashing. It returns the number of tracks added.
+func appendTrackTemplates(projectPath string, templatePaths []string) (int, error) {
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return 0, err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return 0, fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	added := 0
+	for _, path := range templatePaths {
+		tmpl, err := rpp.ParseFile(path)
+		if err != nil {
+			return added, fmt.Errorf("failed to parse track template %s: %w", path, err)
+		}
+
+		tracks := tmpl.Chunks("TRACK")
+		if len(tracks) == 0 {
+			return added, fmt.Errorf("track template %s contains no tracks", path)
+		}
+
+		guids := make(map[string]string)
+		for _, track := range tracks {
+			regenerateGUIDs(track, guids)
+			project.InsertChild(trackInsertIndex(project), track)
+			added++
+		}
+	}
+
+	if err := doc.WriteFile(projectPath); err != nil {
+		return added, err
+	}
+	return added, nil
+}
+
+// trackInsertIndex returns the position after the project's last track, or
+// before the extensions chunk when the project has no tracks
+func trackInsertIndex(project *rpp.Node) int {
+	last := -1
+	for i, c := range project.Children {
+		if c.IsChunk() && c.Name == "TRACK" {
+			last = i
+		}
+	}
+	if last >= 0 {
+		return last + 1
+	}
+	for i, c := range project.Children {
+		if c.IsChunk() && c.Name == "EXTENSIONS" {
+			return i
+		}
+	}
+	return len(project.Children)
+}
+
+// regenerateGUIDs replaces every GUID in node's lines with a fresh one,
+// mapping repeated GUIDs consistently through guids
+func regenerateGUIDs(node *rpp.Node, guids map[string]string) {
+	node.Walk(func(n *rpp.Node) bool {
+		changed := false
+		params := make([]string, len(n.Params))
+		for i, p := range n.Params {
+			params[i] = guidPattern.ReplaceAllStringFunc(p, func(old string) string {
+				changed = true
+				key := strings.ToUpper(old)
+				if g, ok := guids[key]; ok {
+					return g
+				}
+				g := newGUID()
+				guids[key] = g
+				return g
+			})
+		}
+		if changed {
+			n.SetParams(params...)
+		}
+		return true
+	})
+}
+
+// newGUID returns a random GUID in REAPER's {XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX} form
+func newGUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("{%X-%X-%X-%X-%X}", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,347 @@
+package tool
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/johnjallday/music_project_manager/internal/rpp"
+	"github.com/johnjallday/music_project_manager/internal/types"
+	"github.com/johnjallday/ori-agent/pluginapi"
+)
+
+// tempoShapes names REAPER's envelope point shapes
+var tempoShapes = []string{"linear", "square", "slow start/end", "fast start", "fast end", "bezier"}
+
+// tempoPoint is one point of a project's tempo map. Numerator and Denominator
+// are set only on points that change the time signature.
+type tempoPoint struct {
+	Time        float64
+	BPM         float64
+	Shape       int
+	Numerator   int
+	Denominator int
+}
+
+// Ramps reports whether the tempo changes gradually towards the next point.
+// Curved shapes are treated as linear ramps.
+func (p tempoPoint) Ramps() bool {
+	return p.Shape != 1
+}
+
+// tempoMap is a project's tempo map: the header tempo followed by the points of
+// the TEMPOENVEX envelope, sorted by time. The first point is always at 0.
+type tempoMap struct {
+	Points []tempoPoint
+}
+
+// readTempoMap reads the tempo map of a parsed project
+func readTempoMap(project *rpp.Node) tempoMap {
+	first := tempoPoint{BPM: 120, Shape: 1, Numerator: 4, Denominator: 4}
+	if tempo := project.Child("TEMPO"); tempo != nil {
+		if bpm := tempo.ParamFloat(0); bpm > 0 {
+			first.BPM = bpm
+		}
+		if num, denom := tempo.ParamInt(1), tempo.ParamInt(2); num > 0 && denom > 0 {
+			first.Numerator, first.Denominator = num, denom
+		}
+	}
+
+	tm := tempoMap{Points: []tempoPoint{first}}
+
+	env := project.Child("TEMPOENVEX")
+	if env == nil {
+		return tm
+	}
+
+	for _, pt := range env.ChildrenNamed("PT") {
+		point := tempoPoint{
+			Time:  pt.ParamFloat(0),
+			BPM:   pt.ParamFloat(1),
+			Shape: pt.ParamInt(2),
+		}
+		if point.BPM <= 0 {
+			continue
+		}
+		if sig := pt.ParamInt(3); sig > 0 {
+			point.Numerator, point.Denominator = decodeTimeSignature(sig)
+		}
+
+		// A point at the start replaces the header tempo
+		if point.Time <= 1e-9 {
+			point.Time = 0
+			if point.Numerator == 0 {
+				point.Numerator, point.Denominator = first.Numerator, first.Denominator
+			}
+			tm.Points[0] = point
+			continue
+		}
+		tm.Points = append(tm.Points, point)
+	}
+
+	sort.SliceStable(tm.Points, func(i, j int) bool {
+		return tm.Points[i].Time < tm.Points[j].Time
+	})
+
+	return tm
+}
+
+// loadTempoMap parses a project file and reads its tempo map
+func loadTempoMap(projectPath string) (tempoMap, error) {
+	doc, err := rpp.ParseFile(projectPath)
+	if err != nil {
+		return tempoMap{}, err
+	}
+
+	project := doc.Project()
+	if project == nil {
+		return tempoMap{}, fmt.Errorf("%s is not a REAPER project", projectPath)
+	}
+
+	return readTempoMap(project), nil
+}
+
+// decodeTimeSignature unpacks a time signature stored in a tempo envelope point
+func decodeTimeSignature(sig int) (int, int) {
+	return sig & 0xFFFF, sig >> 16
+}
+
+// Initial returns the tempo at the start of the project
+func (tm tempoMap) Initial() float64 {
+	return tm.Points[0].BPM
+}
+
+// Range returns the lowest and highest tempo in the project
+func (tm tempoMap) Range() (float64, float64) {
+	lo, hi := tm.Points[0].BPM, tm.Points[0].BPM
+	for _, p := range tm.Points[1:] {
+		lo = math.Min(lo, p.BPM)
+		hi = math.Max(hi, p.BPM)
+	}
+	return lo, hi
+}
+
+// Changes returns the number of tempo or time signature changes after the start
+func (tm tempoMap) Changes() int {
+	return len(tm.Points) - 1
+}
+
+// QuarterNotesAt returns the number of quarter notes from the project start
+// to the given time in seconds
+func (tm tempoMap) QuarterNotesAt(seconds float64) float64 {
+	qn := 0.0
+	for i, p := range tm.Points {
+		end := seconds
+		if i+1 < len(tm.Points) && tm.Points[i+1].Time < seconds {
+			end = tm.Points[i+1].Time
+		}
+		if end <= p.Time {
+			break
+		}
+		qn += tm.segmentQuarterNotes(i, end-p.Time)
+	}
+	return qn
+}
+
+// SecondsAt returns the time in seconds at which the given number of quarter
+// notes from the project start is reached
+func (tm tempoMap) SecondsAt(qn float64) float64 {
+	for i, p := range tm.Points {
+		if i+1 < len(tm.Points) {
+			length := tm.Points[i+1].Time - p.Time
+			if segment := tm.segmentQuarterNotes(i, length); qn > segment {
+				qn -= segment
+				continue
+			}
+		}
+
+		start, slope := tm.segmentRamp(i)
+		if slope == 0 {
+			return p.Time + qn*60/start
+		}
+		// Solve qn = (start*t + slope*t²/2) / 60 for t
+		return p.Time + (-start+math.Sqrt(start*start+2*slope*qn*60))/slope
+	}
+	return 0
+}
+
+// segmentQuarterNotes returns the quarter notes covered by the first
+// duration seconds of segment i
+func (tm tempoMap) segmentQuarterNotes(i int, duration float64) float64 {
+	start, slope := tm.segmentRamp(i)
+	return (start*duration + slope*duration*duration/2) / 60
+}
+
+// segmentRamp returns the starting tempo of segment i and how fast it changes
+// in BPM per second
+func (tm tempoMap) segmentRamp(i int) (float64, float64) {
+	p := tm.Points[i]
+	if !p.Ramps() || i+1 >= len(tm.Points) {
+		return p.BPM, 0
+	}
+	next := tm.Points[i+1]
+	if next.Time <= p.Time {
+		return p.BPM, 0
+	}
+	return p.BPM, (next.BPM - p.BPM) / (next.Time - p.Time)
+}
+
+// TempoAt returns the tempo at the given time in seconds
+func (tm tempoMap) TempoAt(seconds float64) float64 {
+	i := 0
+	for i+1 < len(tm.Points) && tm.Points[i+1].Time <= seconds {
+		i++
+	}
+	start, slope := tm.segmentRamp(i)
+	return start + slope*(seconds-tm.Points[i].Time)
+}
+
+// Position returns the musical position of a time in seconds as
+// bar.beat.hundredths, counted from bar 1 like REAPER's ruler. Time
+// signature changes start a new bar.
+func (tm tempoMap) Position(seconds float64) string {
+	bar := 1.0
+	sigStart := 0.0
+	num, denom := tm.Points[0].Numerator, tm.Points[0].Denominator
+
+	for _, p := range tm.Points[1:] {
+		if p.Time > seconds {
+			break
+		}
+		if p.Numerator == 0 {
+			continue
+		}
+		qnAtChange := tm.QuarterNotesAt(p.Time)
+		bar += math.Ceil((qnAtChange-sigStart)/quarterNotesPerBar(num, denom) - 1e-9)
+		sigStart = qnAtChange
+		num, denom = p.Numerator, p.Denominator
+	}
+
+	beats := (tm.QuarterNotesAt(seconds) - sigStart) * float64(denom) / 4
+	// Round to hundredths first so 3.999 beats shows as the next beat
+	hundredths := int(math.Round(beats * 100))
+	bars := hundredths / (num * 100)
+	hundredths -= bars * num * 100
+
+	return fmt.Sprintf("%d.%d.%02d", int(bar)+bars, hundredths/100+1, hundredths%100)
+}
+
+// quarterNotesPerBar returns the length of one bar in quarter notes
+func quarterNotesPerBar(num, denom int) float64 {
+	return float64(num) * 4 / float64(denom)
+}
+
+// tempoShapeName returns the name of a tempo envelope point shape
+func tempoShapeName(shape int) string {
+	if shape >= 0 && shape < len(tempoShapes) {
+		return tempoShapes[shape]
+	}
+	return strconv.Itoa(shape)
+}
+
+// applyTempoInfo records a tempo map's initial tempo, range and change count on a project
+func applyTempoInfo(project *types.Project, tm tempoMap) {
+	project.BPM = tm.Initial()
+	project.MinBPM, project.MaxBPM = tm.Range()
+	project.TempoChanges = tm.Changes()
+}
+
+// bpmRange returns the lowest and highest tempo recorded for a project,
+// falling back to its initial BPM for catalogs scanned before tempo maps
+func bpmRange(p types.Project) (float64, float64) {
+	if p.MinBPM <= 0 || p.MaxBPM <= 0 {
+		return p.BPM, p.BPM
+	}
+	return p.MinBPM, p.MaxBPM
+}
+
+// matchesBPM reports whether a project passes the bpm, min_bpm and max_bpm
+// filters. Songs with tempo changes match when any part of their tempo range does.
+func matchesBPM(p types.Project, exact, min, max int) bool {
+	lo, hi := bpmRange(p)
+	if exact > 0 && (exact < int(lo) || exact > int(hi)) {
+		return false
+	}
+	if min > 0 && hi < float64(min) {
+		return false
+	}
+	if max > 0 && lo > float64(max) {
+		return false
+	}
+	return true
+}
+
+// formatBPM shows a project's BPM, with its tempo range when the tempo changes
+func formatBPM(p types.Project) string {
+	bpm := strconv.FormatFloat(p.BPM, 'f', -1, 64)
+	lo, hi := bpmRange(p)
+	if lo == hi {
+		return bpm
+	}
+	return fmt.Sprintf("%s (%s–%s)", bpm, strconv.FormatFloat(lo, 'f', -1, 64), strconv.FormatFloat(hi, 'f', -1, 64))
+}
+
+// tempoMapRow is one row of the get_tempo_map result table
+type tempoMapRow struct {
+	Point         int     `json:"point"`
+	Time          string  `json:"time"`
+	Position      string  `json:"position"`
+	BPM           float64 `json:"bpm"`
+	Shape         string  `json:"shape"`
+	TimeSignature string  `json:"time_signature"`
+}
+
+// getTempoMap shows a project's tempo changes, tempo shapes and time signature changes
+func (m *MusicProjectManagerTool) getTempoMap(name, path string) (string, error) {
+	projectPath, err := m.resolveProjectPath(name, path)
+	if err != nil {
+		return "", err
+	}
+
+	tm, err := loadTempoMap(projectPath)
+	if err != nil {
+		return "", err
+	}
+
+	rows := make([]tempoMapRow, len(tm.Points))
+	for i, p := range tm.Points {
+		row := tempoMapRow{
+			Point:    i + 1,
+			Time:     formatSeconds(p.Time),
+			Position: tm.Position(p.Time),
+			BPM:      p.BPM,
+			Shape:    tempoShapeName(p.Shape),
+		}
+		if p.Numerator > 0 {
+			row.TimeSignature = fmt.Sprintf("%d/%d", p.Numerator, p.Denominator)
+		}
+		rows[i] = row
+	}
+
+	result := pluginapi.NewTableResult(
+		"Tempo Map",
+		[]string{"Point", "Time", "Position", "BPM", "Shape", "Time Signature"},
+		rows,
+	)
+
+	lo, hi := tm.Range()
+	if tm.Changes() == 0 {
+		result.Description = fmt.Sprintf("%s has a constant tempo of %s BPM", displayName(projectPath), strconv.FormatFloat(tm.Initial(), 'f', -1, 64))
+	} else {
+		result.Description = fmt.Sprintf("%s starts at %s BPM and ranges from %s to %s BPM with %d tempo changes",
+			displayName(projectPath),
+			strconv.FormatFloat(tm.Initial(), 'f', -1, 64),
+			strconv.FormatFloat(lo, 'f', -1, 64),
+			strconv.FormatFloat(hi, 'f', -1, 64),
+			tm.Changes())
+	}
+
+	return result.ToJSON()
+}
+
+// formatSeconds formats a time in seconds as m:ss.mmm
+func formatSeconds(seconds float64) string {
+	ms := int(math.Round(seconds * 1000))
+	return fmt.Sprintf("%d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+}
@@ -10,7 +10,6 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -43,17 +42,38 @@ func NewMusicProjectManagerTool(name, version, minAgentVersion, apiVersion strin
 func (m *MusicProjectManagerTool) Definition() pluginapi.Tool {
 	return pluginapi.NewTool(
 		"ori-music-project-manager",
-		"Manage Reaper DAW music projects (.RPP files). Use this for creating new music projects, opening existing Reaper projects in the DAW, opening project locations in Finder, scanning for project files, listing projects, filtering by BPM, and renaming projects. Examples: 'create project mash', 'open project beats', 'open beats in finder', 'show me my 140 BPM projects', 'rename China girl EDM to okok'",
+		"Manage Reaper DAW music projects (.RPP files). Use this for creating new music projects from a named template plus modular track templates (optionally named by a naming scheme with auto-numbering), creating a batch of projects from a YAML, JSON or CSV song list, listing templates, saving a project as a new template, opening existing Reaper projects in the DAW, opening project locations in Finder, scanning for project files, listing projects sorted by date, name, BPM, length or rating, filtering by BPM (aware of tempo changes), key (detected from the MIDI in each project), length or tag, summarizing a project's arrangement (length, sections, tracks, FX, media, last render), checking the audio files a project uses (missing files, sample rates, bit depth, length, size, Broadcast Wave and iXML details), finding each project's latest rendered mixdown and flagging projects changed since their last render, measuring the loudness of rendered WAV and FLAC mixdowns (integrated LUFS, true peak, loudness range) against streaming targets, reading a project's tempo map, changing the tempo of an existing project (optionally rescaling items and markers), listing markers and regions (song sections such as Intro, Verse, Drop) with times in seconds and bars, exporting them as cue sheets, CSV, Audacity labels or FFmpeg chapters, exporting MIDI items (chord progressions, melodies) to .mid files with the tempo map, renaming projects, tagging projects by genre, client, album or mood, and tracking each song's lifecycle status (idea, writing, arranging, mixing, mastering, released, abandoned), keeping per-project metadata such as collaborators and key, reading or writing the REAPER project notes (lyrics ideas, todo lists, mix feedback), and rating projects (1-5 stars) or marking favorites. Metadata is stored in a <project name>.ori-project.json file next to each .RPP. Examples: 'create project mash', 'create a trap beat called mash from my 808 template', 'set up the sessions in ~/album.yaml', 'what templates do I have', 'save beats as a template called Trap Starter', 'open project beats', 'open beats in finder', 'show me my 140 BPM projects', 'rename China girl EDM to okok', 'tag beats as genre:trap', 'show my client:acme projects', 'move beats to mixing', 'show my status board', 'add \"bass too muddy\" to the notes of beats', 'rate beats 5 stars', 'describe beats', 'are any files missing in beats', 'which samples in beats are at 44.1k', 'which songs need a re-render', 'which masters are too quiet for streaming', 'show the tempo map of beats', 'make beats 128 BPM and keep it on the grid', 'how long is the drop in beats', 'make a cue sheet for my mix', 'export the MIDI from beats', 'show my favorite 140 BPM ideas rated 4 or more', 'find unfinished loops under 30 seconds', 'find ideas in A minor around 90 BPM for a mashup'",
 		pluginapi.ObjectProperty("", map[string]interface{}{
 			"operation": pluginapi.StringEnumProperty(
-				"Music project operation: create new Reaper project, open existing project in Reaper DAW, reveal project in Finder file browser, scan for .RPP files, list projects, filter by name/BPM, or rename an existing project",
-				[]string{"create_project", "scan", "list_projects", "open_project", "open_in_finder", "filter_project", "rename_project"},
+				"Music project operation: create new Reaper project, create many projects at once from a YAML/JSON/CSV spec file, open existing project in Reaper DAW, reveal project in Finder file browser, scan for .RPP files, list projects, filter by name/BPM/key/length/tag, rename an existing project, add/remove/list project tags, set a project's lifecycle status, show projects grouped by status, read/update a project's metadata (collaborators, key, notes, custom fields), read, replace, append to and search the project notes stored in the .RPP file, rate and favorite projects, summarize a project's arrangement, list the media files a project uses with sample rate, bit depth, length and size, list each project's latest rendered mixdown and whether it needs a re-render, measure the loudness (integrated LUFS, true peak, loudness range) of rendered mixdowns, show a project's tempo map (tempo changes, shapes and time signature changes), change an existing project's tempo, list markers and regions, export them as cue sheet/CSV/Audacity labels/FFmpeg chapters, export MIDI items with the tempo map to Standard MIDI Files, list available project and track templates, or save an existing project as a reusable template",
+				[]string{"create_project", "create_batch", "scan", "list_projects", "open_project", "open_in_finder", "filter_project", "rename_project", "tag_project", "untag_project", "list_tags", "set_status", "status_board", "get_metadata", "set_metadata", "get_notes", "set_notes", "append_notes", "search_notes", "rate_project", "favorite_project", "describe_project", "check_media", "list_renders", "analyze_render", "get_tempo_map", "set_bpm", "list_markers", "export_markers", "export_midi", "list_templates", "save_as_template"},
 			),
-			"name":     pluginapi.StringProperty("Project name for creating new Reaper projects, filtering existing ones, finding projects to open in Finder, or the current name of a project to rename (e.g., 'mash', 'beats', 'Rich Daddy', 'China girl EDM')"),
-			"new_name": pluginapi.StringProperty("New name for the project when using rename_project operation (e.g., 'okok')"),
-			"path":     pluginapi.StringProperty("Full file path to a Reaper project file (.RPP) to open in Reaper DAW or reveal in Finder (e.g., '/Users/name/Music/Projects/song.RPP')"),
+			"name":           pluginapi.StringProperty("Project name for creating new Reaper projects, filtering existing ones, finding projects to open in Finder, or the current name of a project to rename (e.g., 'mash', 'beats', 'Rich Daddy', 'China girl EDM')"),
+			"time_signature": pluginapi.StringProperty("Time signature for create_project (e.g., '4/4', '6/8', '7/8')"),
+			"sample_rate": pluginapi.WithMinMax(
+				pluginapi.IntegerProperty("Project sample rate in Hz for create_project (22050, 32000, 44100, 48000, 88200, 96000, 176400 or 192000)"),
+				22050,
+				192000,
+			),
+			"length_bars": pluginapi.WithMinMax(
+				pluginapi.IntegerProperty("Default project length in bars for create_project; sets the project end marker"),
+				1,
+				999,
+			),
+			"grid":          pluginapi.StringProperty("Grid division for create_project (e.g., '1/16', '1/8T' for triplets, '1/4D' for dotted)"),
+			"naming_scheme": pluginapi.StringProperty("Naming scheme for create_project, overriding the configured one. Tokens: {name}, {date}, {bpm}, {key}, {n} (auto-incrementing counter, {n:3} zero-pads to 3 digits). Examples: '{date}_{name}_{bpm}bpm', 'Idea {n:3}'"),
+			"on_conflict": pluginapi.StringEnumProperty(
+				"What create_project does when a project folder with the same name already exists: 'error' (default) refuses, 'suffix' creates 'Name (2)' instead, 'open_existing' opens the existing project",
+				conflictModes,
+			),
+			"launch":          booleanProperty("Whether create_project opens the new project in REAPER (default true). Set to false to prepare projects headless or in batch"),
+			"template":        pluginapi.StringProperty("Template for create_project: a template name from the template directory (e.g., '808', 'Vocal Session') or a full path to a .RPP file. Uses the default template when omitted. For save_as_template, the name of the new template (defaults to the project name)"),
+			"track_templates": stringArrayProperty("Track templates (.RTrackTemplate names or paths) to append to the new project's track list with create_project (e.g., ['Drum Bus', 'Vocal Chain', 'Reference'])"),
+			"description":     pluginapi.StringProperty("Description stored in the template's notes for save_as_template (e.g., 'Trap beat: 808, drum bus, vocal chain')"),
+			"new_name":        pluginapi.StringProperty("New name for the project when using rename_project operation (e.g., 'okok')"),
+			"path":            pluginapi.StringProperty("Full file path to a Reaper project file (.RPP) to open in Reaper DAW, reveal in Finder, or read/edit notes in (e.g., '/Users/name/Music/Projects/song.RPP'), or to the .yaml, .json or .csv spec file for create_batch"),
 			"bpm": pluginapi.WithMinMax(
-				pluginapi.IntegerProperty("BPM for the project (optional for create_project, exact BPM for filter_project)"),
+				pluginapi.IntegerProperty("BPM for the project (optional for create_project, new tempo for set_bpm, exact BPM for filter_project; songs with tempo changes match any BPM in their range)"),
 				30,
 				300,
 			),
@@ -67,6 +87,36 @@ func (m *MusicProjectManagerTool) Definition() pluginapi.Tool {
 				30,
 				300,
 			),
+			"rescale":   booleanProperty("With set_bpm, also move items, markers and automation so the arrangement stays on the grid; audio items are time-stretched (default false)"),
+			"per_track": booleanProperty("With export_midi, write one .mid file per track instead of one multitrack file (default false)"),
+			"format":    pluginapi.StringEnumProperty("File format for export_markers: cue sheet, CSV, Audacity label track, FFmpeg chapter metadata, or all of them (default)", append(append([]string{}, markerFormats...), "all")),
+			"tags":      stringArrayProperty("Tags to add with tag_project, remove with untag_project, or give new projects with create_project and create_batch (e.g., ['genre:trap', 'client:acme', 'album:summer', 'mood:dark'])"),
+			"tag":       pluginapi.StringProperty("Single tag for filter_project, tag_project or untag_project (e.g., 'genre:trap')"),
+			"status": pluginapi.StringEnumProperty(
+				"Lifecycle status for set_status, or status filter for filter_project",
+				projectStatuses,
+			),
+			"collaborators": stringArrayProperty("Collaborators to store with set_metadata (replaces the existing list)"),
+			"key":           pluginapi.StringProperty("Musical key for create_project (adds a key marker and fills {{KEY}} placeholders), to store with set_metadata, or to filter by with filter_project, which also matches keys detected from MIDI (e.g., 'F# minor', 'Am')"),
+			"notes":         pluginapi.StringProperty("Project notes text for set_notes/append_notes (written into the .RPP, e.g. lyric ideas, todo lists, mix feedback), or plugin notes for set_metadata"),
+			"rating": pluginapi.WithMinMax(
+				pluginapi.IntegerProperty("Star rating for rate_project (1-5, or 0 to clear the rating)"),
+				0,
+				5,
+			),
+			"min_rating": pluginapi.WithMinMax(
+				pluginapi.IntegerProperty("Minimum star rating for list_projects and filter_project (optional)"),
+				1,
+				5,
+			),
+			"favorite":       booleanProperty("Whether favorite_project marks (true, default) or unmarks (false) the project as a favorite"),
+			"favorites_only": booleanProperty("Only show favorite projects in list_projects and filter_project (optional)"),
+			"min_length":     pluginapi.IntegerProperty("Minimum project length in seconds for filter_project (optional)"),
+			"max_length":     pluginapi.IntegerProperty("Maximum project length in seconds for filter_project (optional, e.g. 30 to find short loops)"),
+			"sort":           pluginapi.StringEnumProperty("Sort order for list_projects and filter_project: most recent first (default), name, bpm, length, or rating", projectSorts),
+			"clear":          booleanProperty("With set_notes, remove the project notes. Required to clear them; set_notes without notes text is rejected"),
+			"query":          pluginapi.StringProperty("Text to search for in project notes with search_notes (e.g., 'vocal too loud')"),
+			"custom":         stringMapProperty("Custom fields to merge with set_metadata; an empty value removes the field (e.g., {'label': 'Nightshift'})"),
 		}, []string{"operation"}),
 	)
 }
@@ -81,30 +131,85 @@ func (m *MusicProjectManagerTool) Call(ctx context.Context, args string) (string
 
 	switch params.Operation {
 	case "create_project":
-		return m.createProject(params.Name, params.BPM)
+		return m.createProject(params)
+	case "create_batch":
+		return m.createBatch(params)
 	case "scan":
 		return m.scanProjects()
 	case "list_projects":
-		return m.listProjects()
+		return m.listProjects(params.MinRating, params.FavoritesOnly, params.Sort)
 	case "open_project":
 		return m.openProject(params.Path)
 	case "open_in_finder":
 		return m.openInFinder(params.Path, params.Name)
 	case "filter_project":
-		return m.filterProject(params.Name, params.BPM, params.MinBPM, params.MaxBPM)
+		return m.filterProject(params)
 	case "rename_project":
 		return m.renameProject(params.Name, params.NewName)
+	case "tag_project":
+		return m.tagProject(params.Name, withTag(params.Tags, params.Tag))
+	case "untag_project":
+		return m.untagProject(params.Name, withTag(params.Tags, params.Tag))
+	case "list_tags":
+		return m.listTags()
+	case "set_status":
+		return m.setStatus(params.Name, params.Status)
+	case "status_board":
+		return m.statusBoard()
+	case "get_metadata":
+		return m.getMetadata(params.Name)
+	case "set_metadata":
+		return m.setMetadata(params)
+	case "get_notes":
+		return m.getNotes(params.Name, params.Path)
+	case "set_notes":
+		return m.setNotes(params.Name, params.Path, params.Notes, false, params.Clear)
+	case "append_notes":
+		return m.setNotes(params.Name, params.Path, params.Notes, true, params.Clear)
+	case "search_notes":
+		return m.searchNotes(params.Query)
+	case "rate_project":
+		return m.rateProject(params.Name, params.Rating)
+	case "favorite_project":
+		return m.favoriteProject(params.Name, params.Favorite)
+	case "list_markers":
+		return m.listMarkers(params.Name, params.Path)
+	case "export_markers":
+		return m.exportMarkers(params.Name, params.Path, params.Format)
+	case "export_midi":
+		return m.exportMIDI(params.Name, params.Path, params.PerTrack)
+	case "set_bpm":
+		return m.setBPM(params.Name, params.Path, params.BPM, params.Rescale)
+	case "describe_project":
+		return m.describeProject(params.Name, params.Path)
+	case "list_renders":
+		return m.listRenders(params.Name)
+	case "analyze_render":
+		return m.analyzeRender(params.Name, params.Path)
+	case "check_media":
+		return m.checkMedia(params.Name, params.Path)
+	case "get_tempo_map":
+		return m.getTempoMap(params.Name, params.Path)
+	case "list_templates":
+		return m.listTemplates()
+	case "save_as_template":
+		return m.saveAsTemplate(params.Name, params.Template, params.Description)
 	default:
-		return "", fmt.Errorf("unknown operation %q. Valid operations: create_project, scan, list_projects, open_project, open_in_finder, filter_project, rename_project", params.Operation)
+		return "", fmt.Errorf("unknown operation %q. Valid operations: create_project, create_batch, scan, list_projects, open_project, open_in_finder, filter_project, rename_project, tag_project, untag_project, list_tags, set_status, status_board, get_metadata, set_metadata, get_notes, set_notes, append_notes, search_notes, rate_project, favorite_project, describe_project, check_media, list_renders, analyze_render, get_tempo_map, set_bpm, list_markers, export_markers, export_midi, list_templates, save_as_template", params.Operation)
 	}
 }
 
-// createProject creates a new music project
-func (m *MusicProjectManagerTool) createProject(name string, bpm int) (string, error) {
-	if err := validateCreateProject(name, bpm); err != nil {
-		return "", err
-	}
+// createdProject describes the outcome of creating a single project
+type createdProject struct {
+	Name     string
+	Path     string
+	Existing bool
+	Message  string
+}
 
+// createProject creates a new music project from the named template, or the
+// default template when none is given
+func (m *MusicProjectManagerTool) createProject(params types.MusicProjectParams) (string, error) {
 	settings, err := m.loadSettings()
 	if err != nil {
 		return "", fmt.Errorf("failed to load settings: %w", err)
@@ -114,50 +219,225 @@ func (m *MusicProjectManagerTool) createProject(name string, bpm int) (string, e
 		return "Music Project Manager needs to be configured. Please set project_dir and template_dir in the application settings.", nil
 	}
 
+	created, err := m.newProject(settings, params)
+	if err != nil {
+		return "", err
+	}
+	return created.Message, nil
+}
+
+// newProject creates, registers and optionally launches one project in the
+// configured project directory
+func (m *MusicProjectManagerTool) newProject(settings *types.Settings, params types.MusicProjectParams) (createdProject, error) {
+	bpm, template := params.BPM, params.Template
+
+	params.OnConflict = strings.ToLower(strings.TrimSpace(params.OnConflict))
+	if params.OnConflict != "" && !containsTag(conflictModes, params.OnConflict) {
+		return createdProject{}, fmt.Errorf("on_conflict must be one of %s, got %q", strings.Join(conflictModes, ", "), params.OnConflict)
+	}
+
+	setup, err := parseMusicalSetup(params)
+	if err != nil {
+		return createdProject{}, err
+	}
+
 	projectDirBase := settings.ProjectDir
-	templateDir := settings.TemplateDir
 
-	defaultTemplate := filepath.Join(templateDir, "default.RPP")
+	templatePath, err := resolveTemplate(settings, template)
+	if err != nil {
+		return createdProject{}, err
+	}
+
+	trackTemplates, err := resolveTrackTemplates(settings, params.TrackTemplates)
+	if err != nil {
+		return createdProject{}, err
+	}
+
+	data, err := os.ReadFile(templatePath)
+	if err != nil {
+		if os.IsNotExist(err) && template == "" {
+			return createdProject{}, fmt.Errorf("template file not found at %q. Please ensure a default.RPP template exists in your template directory", templatePath)
+		}
+		if os.IsNotExist(err) {
+			return createdProject{}, fmt.Errorf("template file not found at %q", templatePath)
+		}
+		return createdProject{}, fmt.Errorf("failed to read template file %q: %w", templatePath, err)
+	}
+
+	// Build the project name from the naming scheme, if one is configured
+	scheme := params.NamingScheme
+	if scheme == "" {
+		scheme = settings.NamingScheme
+	}
+	if params.Name == "" && namingUsesName(scheme) {
+		return createdProject{}, fmt.Errorf("project name is required and cannot be empty")
+	}
+
+	values := namingValues{Name: params.Name, BPM: float64(bpm), Key: setup.Key}
+	if values.BPM == 0 {
+		values.BPM, _ = extractBPMFromRPP(templatePath)
+	}
+	name, err := buildProjectName(scheme, projectDirBase, values)
+	if err != nil {
+		return createdProject{}, err
+	}
+	params.Name = name
+
+	if err := validateCreateProject(name, bpm); err != nil {
+		return createdProject{}, err
+	}
+
 	projectDir := filepath.Join(projectDirBase, name)
 
+	// Never overwrite an existing project
+	if _, err := os.Stat(projectDir); err == nil {
+		switch params.OnConflict {
+		case "", "error":
+			return createdProject{}, fmt.Errorf("a project folder named '%s' already exists at %s. Use on_conflict 'suffix' to create '%s' instead, or 'open_existing' to open it", name, projectDir, uniqueProjectName(projectDirBase, name))
+		case "open_existing":
+			return m.openExistingProject(projectDir, name, projectDirBase, shouldLaunch(params.Launch))
+		case "suffix":
+			name = uniqueProjectName(projectDirBase, name)
+			params.Name = name
+			projectDir = filepath.Join(projectDirBase, name)
+		}
+	}
+
 	if err := os.MkdirAll(projectDir, 0o755); err != nil {
-		return "", fmt.Errorf("failed to create project directory %q: %w", projectDir, err)
+		return createdProject{}, fmt.Errorf("failed to create project directory %q: %w", projectDir, err)
 	}
 
 	dest := filepath.Join(projectDir, name+".RPP")
-	data, err := os.ReadFile(defaultTemplate)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return "", fmt.Errorf("template file not found at %q. Please ensure a default.RPP template exists in your template directory", defaultTemplate)
+
+	// Build the project on disk and register it; remove the folder again if any step fails
+	// so neither disk nor catalog is left with a half-created project
+	if err := buildProject(dest, data, params, settings, setup, trackTemplates); err != nil {
+		if rmErr := os.RemoveAll(projectDir); rmErr != nil {
+			log.Printf("[music-project-manager] Warning: failed to clean up %s: %v", projectDir, rmErr)
+		}
+		return createdProject{}, err
+	}
+
+	msg := fmt.Sprintf("Created project: %s", dest)
+	if bpm > 0 {
+		msg += fmt.Sprintf(" (BPM %d)", bpm)
+	}
+	if details := describeSetup(setup); details != "" {
+		msg += fmt.Sprintf("\nSetup: %s", details)
+	}
+	msg += fmt.Sprintf("\nTemplate: %s", templateName(templatePath))
+	if len(trackTemplates) > 0 {
+		var names []string
+		for _, path := range trackTemplates {
+			names = append(names, templateName(path))
 		}
-		return "", fmt.Errorf("failed to read template file %q: %w", defaultTemplate, err)
+		msg += fmt.Sprintf("\nTrack templates: %s", strings.Join(names, ", "))
 	}
 
+	// The project is complete at this point; launching is a separate step
+	if shouldLaunch(params.Launch) {
+		msg += launchStatus(dest)
+	}
+	return createdProject{Name: name, Path: dest, Message: msg}, nil
+}
+
+// buildProject writes a new project file from template data, applies the
+// requested setup and registers it in projects.json
+func buildProject(dest string, data []byte, params types.MusicProjectParams, settings *types.Settings, setup musicalSetup, trackTemplates []string) error {
+	bpm := params.BPM
+
 	if err := os.WriteFile(dest, data, 0o644); err != nil {
-		return "", fmt.Errorf("failed to write project file: %w", err)
+		return fmt.Errorf("failed to write project file: %w", err)
 	}
 
 	if bpm > 0 {
 		if err := updateProjectBPM(dest, bpm); err != nil {
-			return "", fmt.Errorf("failed to update BPM in project file: %w", err)
+			return fmt.Errorf("failed to update BPM in project file: %w", err)
 		}
 	}
 
-	if err := launchReaper(dest); err != nil {
-		return "", fmt.Errorf("failed to launch Reaper: %w", err)
+	if !setup.IsEmpty() {
+		if err := applyMusicalSetup(dest, setup); err != nil {
+			return fmt.Errorf("failed to apply musical setup to project file: %w", err)
+		}
 	}
 
-	// Append the new project to projects.json
-	if err := m.appendProjectToJSON(dest, name, projectDirBase); err != nil {
-		// Log the error but don't fail the operation since the project was created successfully
-		log.Printf("[music-project-manager] Warning: failed to update projects.json: %v", err)
+	// Add modular track templates (drum bus, vocal chain, ...) to the base template
+	if len(trackTemplates) > 0 {
+		if _, err := appendTrackTemplates(dest, trackTemplates); err != nil {
+			return fmt.Errorf("failed to add track templates: %w", err)
+		}
 	}
 
-	msg := fmt.Sprintf("Created and launched project: %s", dest)
-	if bpm > 0 {
-		msg += fmt.Sprintf(" (BPM %d)", bpm)
+	// Remember the key and tags in the project's metadata sidecar
+	if meta := (types.ProjectMetadata{Key: setup.Key, Tags: normalizeTags(params.Tags)}); !isEmptyMetadata(meta) {
+		if err := writeSidecar(dest, meta); err != nil {
+			log.Printf("[music-project-manager] Warning: failed to write project metadata: %v", err)
+		}
+	}
+
+	// Fill in {{PROJECT_NAME}}, {{DATE}} and friends left in the template
+	projectBPM, err := extractBPMFromRPP(dest)
+	if err != nil {
+		projectBPM = float64(bpm)
+	}
+	if err := applyTemplateVariables(dest, templateVariables(settings, params, projectBPM)); err != nil {
+		return fmt.Errorf("failed to fill in template placeholders: %w", err)
+	}
+
+	if err := registerProject(dest, params.Name, settings.ProjectDir); err != nil {
+		return fmt.Errorf("failed to register project in projects.json: %w", err)
+	}
+
+	return nil
+}
+
+// openExistingProject registers and opens the project already stored in projectDir
+func (m *MusicProjectManagerTool) openExistingProject(projectDir, name, projectDirBase string, launch bool) (createdProject, error) {
+	projectPath := filepath.Join(projectDir, name+".RPP")
+	if _, err := os.Stat(projectPath); err != nil {
+		entries, err := os.ReadDir(projectDir)
+		if err != nil {
+			return createdProject{}, fmt.Errorf("failed to read project folder %s: %w", projectDir, err)
+		}
+		projectPath = ""
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".rpp") {
+				projectPath = filepath.Join(projectDir, entry.Name())
+				break
+			}
+		}
+		if projectPath == "" {
+			return createdProject{}, fmt.Errorf("project folder %s exists but contains no .RPP file", projectDir)
+		}
+	}
+
+	if err := registerProject(projectPath, name, projectDirBase); err != nil {
+		return createdProject{}, fmt.Errorf("failed to register project in projects.json: %w", err)
+	}
+
+	msg := fmt.Sprintf("Project '%s' already exists, using it instead: %s", name, projectPath)
+	if launch {
+		msg += launchStatus(projectPath)
+	}
+	return createdProject{Name: name, Path: projectPath, Existing: true, Message: msg}, nil
+}
+
+// shouldLaunch reports whether create_project opens the new project in REAPER,
+// which it does unless launch is explicitly false
+func shouldLaunch(launch *bool) bool {
+	return launch == nil || *launch
+}
+
+// launchStatus launches REAPER with a project and describes the outcome for
+// the result message. A failed launch is only a warning since the project is
+// already on disk and in projects.json.
+func launchStatus(projectPath string) string {
+	if err := launchReaper(projectPath); err != nil {
+		log.Printf("[music-project-manager] Warning: failed to launch Reaper with %s: %v", projectPath, err)
+		return fmt.Sprintf("\nWarning: could not launch REAPER, open the project later: %v", err)
 	}
-	return msg, nil
+	return "\nOpened in REAPER"
 }
 
 // openProject opens an existing project using launchReaper
@@ -285,6 +565,7 @@ func (m *MusicProjectManagerTool) scanProjects() (string, error) {
 		log.Printf("[music-project-manager] Starting background scan of %s", projectDir)
 
 		var projects []types.Project
+		hasSidecar := make(map[string]bool)
 
 		err := filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
@@ -293,20 +574,28 @@ func (m *MusicProjectManagerTool) scanProjects() (string, error) {
 
 			// Check if file has .RPP extension (Reaper project files)
 			if strings.ToLower(filepath.Ext(path)) == ".rpp" {
-				// Extract BPM from the RPP file
-				bpm, err := extractBPMFromRPP(path)
-				if err != nil {
-					log.Printf("[music-project-manager] Warning: failed to extract BPM from %s: %v", path, err)
-					bpm = 0 // Use 0 as default if extraction fails
-				}
-
 				project := types.Project{
-					Name:         strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+					Name:         displayName(path),
 					Path:         path,
 					LastModified: info.ModTime(),
 					Size:         info.Size(),
-					BPM:          bpm,
 				}
+
+				// Read the full tempo map so songs with tempo changes report their range,
+				// and the project length from its items and regions
+				if err := readProjectInfo(&project); err != nil {
+					log.Printf("[music-project-manager] Warning: failed to read %s: %v", path, err)
+				}
+
+				// Merge the plugin's own metadata from the sidecar file
+				meta, err := readSidecar(path)
+				if err != nil {
+					log.Printf("[music-project-manager] Warning: %s: %v", path, err)
+				} else if meta != nil {
+					project.ProjectMetadata = *meta
+					hasSidecar[path] = true
+				}
+
 				projects = append(projects, project)
 			}
 			return nil
@@ -316,6 +605,11 @@ func (m *MusicProjectManagerTool) scanProjects() (string, error) {
 			return
 		}
 
+		// Move metadata from older catalogs into sidecars so it is not lost
+		if previous, err := loadCatalog(projectDir); err == nil {
+			migrateCatalogMetadata(projects, previous, hasSidecar)
+		}
+
 		// Create projects.json file in the project directory
 		projectsFile := filepath.Join(projectDir, "projects.json")
 
@@ -337,8 +631,14 @@ func (m *MusicProjectManagerTool) scanProjects() (string, error) {
 	return fmt.Sprintf("Scanning %s in the background. Use 'list_projects' to see results once complete.", projectDir), nil
 }
 
-// listProjects reads and returns the 30 most recent projects as a structured table result
-func (m *MusicProjectManagerTool) listProjects() (string, error) {
+// listProjects reads and returns the 30 most recent projects as a structured table result,
+// optionally limited to rated or favorite projects or sorted by another field
+func (m *MusicProjectManagerTool) listProjects(minRating int, favoritesOnly bool, sortBy string) (string, error) {
+	sortBy, err := parseProjectSort(sortBy)
+	if err != nil {
+		return "", err
+	}
+
 	settings, err := m.loadSettings()
 	if err != nil {
 		return "", fmt.Errorf("failed to load settings: %w", err)
@@ -372,10 +672,22 @@ func (m *MusicProjectManagerTool) listProjects() (string, error) {
 		return fmt.Sprintf("No projects found in %s", projectsFile), nil
 	}
 
-	// Sort projects by LastModified time in descending order (most recent first)
-	sort.Slice(projects, func(i, j int) bool {
-		return projects[i].LastModified.After(projects[j].LastModified)
-	})
+	// Keep only rated/favorite projects if requested
+	if minRating > 0 || favoritesOnly {
+		var rated []types.Project
+		for _, proj := range projects {
+			if matchesRating(proj, minRating, favoritesOnly) {
+				rated = append(rated, proj)
+			}
+		}
+		if len(rated) == 0 {
+			return "No projects match the rating criteria", nil
+		}
+		projects = rated
+	}
+
+	// Most recent first unless another sort order was requested
+	sortProjects(projects, sortBy)
 
 	// Take only the first 30 projects (or fewer if less than 30 exist)
 	limit := 30
@@ -386,36 +698,69 @@ func (m *MusicProjectManagerTool) listProjects() (string, error) {
 
 	// Create simplified output with only name, path, and date
 	type SimplifiedProject struct {
-		Name string  `json:"name"`
-		Path string  `json:"path"`
-		Date string  `json:"date"`
-		BPM  float64 `json:"bpm"`
+		Name     string `json:"name"`
+		Path     string `json:"path"`
+		Date     string `json:"date"`
+		BPM      string `json:"bpm"`
+		Key      string `json:"key"`
+		Length   string `json:"length"`
+		Tags     string `json:"tags"`
+		Status   string `json:"status"`
+		Rating   int    `json:"rating"`
+		Favorite bool   `json:"favorite"`
 	}
 
 	simplified := make([]SimplifiedProject, len(recentProjects))
 	for i, p := range recentProjects {
 		simplified[i] = SimplifiedProject{
-			Name: p.Name,
-			Path: p.Path,
-			Date: p.LastModified.Format("2006-01-02"),
-			BPM:  p.BPM,
+			Name:     p.Name,
+			Path:     p.Path,
+			Date:     p.LastModified.Format("2006-01-02"),
+			BPM:      formatBPM(p),
+			Key:      projectKey(p),
+			Length:   formatLength(p.Length),
+			Tags:     strings.Join(p.Tags, ", "),
+			Status:   p.Status,
+			Rating:   p.Rating,
+			Favorite: p.Favorite,
 		}
 	}
 
 	// Create structured result for table display
 	result := pluginapi.NewTableResult(
 		"Recent Music Projects",
-		[]string{"Name", "Path", "Date", "BPM"},
+		[]string{"Name", "Path", "Date", "BPM", "Key", "Length", "Tags", "Status", "Rating", "Favorite"},
 		simplified,
 	)
 	result.Description = fmt.Sprintf("Showing %d most recent projects", len(simplified))
+	if sortBy != "date" {
+		result.Description = fmt.Sprintf("Showing %d projects sorted by %s", len(simplified), sortBy)
+	}
 
 	// Return as JSON
 	return result.ToJSON()
 }
 
-// filterProject filters projects by name and/or BPM criteria
-func (m *MusicProjectManagerTool) filterProject(nameFilter string, exactBPM, minBPM, maxBPM int) (string, error) {
+// filterProject filters projects by name, BPM, key, length, tag, status and/or rating criteria
+func (m *MusicProjectManagerTool) filterProject(params types.MusicProjectParams) (string, error) {
+	nameFilter := params.Name
+	exactBPM, minBPM, maxBPM := params.BPM, params.MinBPM, params.MaxBPM
+	tagFilter, statusFilter := params.Tag, params.Status
+
+	var keyFilter *musicalKey
+	if params.Key != "" {
+		key, err := parseKey(params.Key)
+		if err != nil {
+			return "", err
+		}
+		keyFilter = &key
+	}
+
+	sortBy, err := parseProjectSort(params.Sort)
+	if err != nil {
+		return "", err
+	}
+
 	settings, err := m.loadSettings()
 	if err != nil {
 		return "", fmt.Errorf("failed to load settings: %w", err)
@@ -457,16 +802,33 @@ func (m *MusicProjectManagerTool) filterProject(nameFilter string, exactBPM, min
 			continue
 		}
 
-		// Filter by exact BPM if specified
-		if exactBPM > 0 && int(proj.BPM) != exactBPM {
+		// Filter by exact BPM or BPM range if specified, using the song's whole tempo range
+		if !matchesBPM(proj, exactBPM, minBPM, maxBPM) {
+			continue
+		}
+
+		// Filter by project length in seconds if specified
+		if !matchesLength(proj, params.MinLength, params.MaxLength) {
+			continue
+		}
+
+		// Filter by key (stored or detected from MIDI) if specified
+		if keyFilter != nil && !matchesKey(proj, *keyFilter) {
 			continue
 		}
 
-		// Filter by BPM range if specified
-		if minBPM > 0 && proj.BPM < float64(minBPM) {
+		// Filter by tag if specified
+		if tagFilter != "" && !containsTag(proj.Tags, tagFilter) {
 			continue
 		}
-		if maxBPM > 0 && proj.BPM > float64(maxBPM) {
+
+		// Filter by lifecycle status if specified
+		if statusFilter != "" && !strings.EqualFold(proj.Status, statusFilter) {
+			continue
+		}
+
+		// Filter by rating and favorites if specified
+		if !matchesRating(proj, params.MinRating, params.FavoritesOnly) {
 			continue
 		}
 
@@ -477,10 +839,8 @@ func (m *MusicProjectManagerTool) filterProject(nameFilter string, exactBPM, min
 		return "No projects match the filter criteria", nil
 	}
 
-	// Sort filtered projects by LastModified time in descending order (most recent first)
-	sort.Slice(filtered, func(i, j int) bool {
-		return filtered[i].LastModified.After(filtered[j].LastModified)
-	})
+	// Most recent first unless another sort order was requested
+	sortProjects(filtered, sortBy)
 
 	// Take only the first 30 projects (or fewer if less than 30 exist)
 	limit := 30
@@ -491,29 +851,44 @@ func (m *MusicProjectManagerTool) filterProject(nameFilter string, exactBPM, min
 
 	// Create simplified output with only name, path, and date
 	type SimplifiedProject struct {
-		Name string  `json:"name"`
-		Path string  `json:"path"`
-		Date string  `json:"date"`
-		BPM  float64 `json:"bpm"`
+		Name     string `json:"name"`
+		Path     string `json:"path"`
+		Date     string `json:"date"`
+		BPM      string `json:"bpm"`
+		Key      string `json:"key"`
+		Length   string `json:"length"`
+		Tags     string `json:"tags"`
+		Status   string `json:"status"`
+		Rating   int    `json:"rating"`
+		Favorite bool   `json:"favorite"`
 	}
 
 	simplified := make([]SimplifiedProject, len(recentProjects))
 	for i, p := range recentProjects {
 		simplified[i] = SimplifiedProject{
-			Name: p.Name,
-			Path: p.Path,
-			Date: p.LastModified.Format("2006-01-02"),
-			BPM:  p.BPM,
+			Name:     p.Name,
+			Path:     p.Path,
+			Date:     p.LastModified.Format("2006-01-02"),
+			BPM:      formatBPM(p),
+			Key:      projectKey(p),
+			Length:   formatLength(p.Length),
+			Tags:     strings.Join(p.Tags, ", "),
+			Status:   p.Status,
+			Rating:   p.Rating,
+			Favorite: p.Favorite,
 		}
 	}
 
 	// Create structured result for table display
 	result := pluginapi.NewTableResult(
 		"Filtered Music Projects",
-		[]string{"Name", "Path", "Date", "BPM"},
+		[]string{"Name", "Path", "Date", "BPM", "Key", "Length", "Tags", "Status", "Rating", "Favorite"},
 		simplified,
 	)
 	result.Description = fmt.Sprintf("Found %d projects matching filters, showing %d most recent", len(filtered), limit)
+	if sortBy != "date" {
+		result.Description = fmt.Sprintf("Found %d projects matching filters, showing %d sorted by %s", len(filtered), limit, sortBy)
+	}
 
 	// Return as JSON
 	return result.ToJSON()
@@ -603,6 +978,14 @@ func (m *MusicProjectManagerTool) renameProject(oldName, newName string) (string
 		return "", fmt.Errorf("failed to rename RPP file: %w", err)
 	}
 
+	// The metadata sidecar is named after the project file too
+	oldSidecar := sidecarPath(tempOldRPPPath)
+	if _, err := os.Stat(oldSidecar); err == nil {
+		if err := os.Rename(oldSidecar, sidecarPath(newRPPPath)); err != nil {
+			log.Printf("[music-project-manager] Warning: failed to rename metadata file: %v", err)
+		}
+	}
+
 	// Step 3: Update projects.json
 	projects[projectIndex].Name = newName
 	projects[projectIndex].Path = newRPPPath
@@ -655,6 +1038,7 @@ func (m *MusicProjectManagerTool) GetRequiredConfig() []pluginapi.ConfigVariable
 	defaultProjectDir := filepath.Join(usr.HomeDir, "Music", "Projects")
 	defaultTemplateDir := filepath.Join(usr.HomeDir, "Library", "Application Support", "REAPER", "ProjectTemplates")
 	defaultTemplatePath := filepath.Join(defaultTemplateDir, "Default.RPP")
+	defaultTrackTemplateDir := filepath.Join(usr.HomeDir, "Library", "Application Support", "REAPER", "TrackTemplates")
 
 	return []pluginapi.ConfigVariable{
 		{
@@ -684,6 +1068,39 @@ func (m *MusicProjectManagerTool) GetRequiredConfig() []pluginapi.ConfigVariable
 			DefaultValue: defaultTemplatePath,
 			Placeholder:  defaultTemplatePath,
 		},
+		{
+			Key:          "track_template_dir",
+			Name:         "Track Template Directory",
+			Description:  "Directory where REAPER track templates (.RTrackTemplate) are stored",
+			Type:         pluginapi.ConfigTypeDirPath,
+			Required:     false,
+			DefaultValue: defaultTrackTemplateDir,
+			Placeholder:  defaultTrackTemplateDir,
+		},
+		{
+			Key:         "render_dir",
+			Name:        "Render Directory",
+			Description: "Directory where mixdowns are rendered, if not inside each project folder. Renders are also looked for in a subfolder named after the project",
+			Type:        pluginapi.ConfigTypeDirPath,
+			Required:    false,
+			Placeholder: filepath.Join(usr.HomeDir, "Music", "Renders"),
+		},
+		{
+			Key:         "naming_scheme",
+			Name:        "Naming Scheme",
+			Description: "Naming scheme for new projects. Tokens: {name}, {date}, {bpm}, {key}, {n} (auto-incrementing counter, {n:3} zero-pads). Leave empty to use the given name",
+			Type:        pluginapi.ConfigTypeString,
+			Required:    false,
+			Placeholder: "{date}_{name}_{bpm}bpm",
+		},
+		{
+			Key:         "artist",
+			Name:        "Artist",
+			Description: "Artist name substituted for {{ARTIST}} placeholders in templates",
+			Type:        pluginapi.ConfigTypeString,
+			Required:    false,
+			Placeholder: "Your artist name",
+		},
 	}
 }
 
@@ -714,6 +1131,10 @@ func (m *MusicProjectManagerTool) InitializeWithConfig(config map[string]interfa
 	projectDir, _ := config["project_dir"].(string)
 	templateDir, _ := config["template_dir"].(string)
 	defaultTemplate, _ := config["default_template"].(string)
+	artist, _ := config["artist"].(string)
+	trackTemplateDir, _ := config["track_template_dir"].(string)
+	namingScheme, _ := config["naming_scheme"].(string)
+	renderDir, _ := config["render_dir"].(string)
 
 	// If default_template is not provided, construct it from template_dir
 	if defaultTemplate == "" {
@@ -722,9 +1143,13 @@ func (m *MusicProjectManagerTool) InitializeWithConfig(config map[string]interfa
 
 	// Create Settings struct from config
 	newSettings := &types.Settings{
-		ProjectDir:      projectDir,
-		TemplateDir:     templateDir,
-		DefaultTemplate: defaultTemplate,
+		ProjectDir:       projectDir,
+		TemplateDir:      templateDir,
+		DefaultTemplate:  defaultTemplate,
+		Artist:           artist,
+		TrackTemplateDir: trackTemplateDir,
+		NamingScheme:     namingScheme,
+		RenderDir:        renderDir,
 	}
 
 	// Update in-memory settings
@@ -821,62 +1246,75 @@ func (m *MusicProjectManagerTool) getDefaultSettings() (*types.Settings, error)
 	}
 
 	return &types.Settings{
-		ProjectDir:      filepath.Join(usr.HomeDir, "Music", "Projects"),
-		TemplateDir:     filepath.Join(usr.HomeDir, "Library", "Application Support", "REAPER", "ProjectTemplates"),
-		DefaultTemplate: filepath.Join(usr.HomeDir, "Library", "Application Support", "REAPER", "ProjectTemplates", "Default.RPP"),
+		ProjectDir:       filepath.Join(usr.HomeDir, "Music", "Projects"),
+		TemplateDir:      filepath.Join(usr.HomeDir, "Library", "Application Support", "REAPER", "ProjectTemplates"),
+		DefaultTemplate:  filepath.Join(usr.HomeDir, "Library", "Application Support", "REAPER", "ProjectTemplates", "Default.RPP"),
+		TrackTemplateDir: filepath.Join(usr.HomeDir, "Library", "Application Support", "REAPER", "TrackTemplates"),
 	}, nil
 }
 
-// appendProjectToJSON appends a newly created project to the projects.json file
-func (m *MusicProjectManagerTool) appendProjectToJSON(projectPath, projectName, projectDirBase string) error {
-	projectsFile := filepath.Join(projectDirBase, "projects.json")
-
+// registerProject adds a project to projects.json, replacing any existing
+// entry for the same path so re-registering never creates duplicates
+func registerProject(projectPath, projectName, projectDirBase string) error {
 	// Get file info for the new project
 	fileInfo, err := os.Stat(projectPath)
 	if err != nil {
 		return fmt.Errorf("failed to stat project file: %w", err)
 	}
 
-	// Extract BPM from the project file
-	bpm, err := extractBPMFromRPP(projectPath)
-	if err != nil {
-		log.Printf("[music-project-manager] Warning: failed to extract BPM from %s: %v", projectPath, err)
-		bpm = 0 // Use 0 as default if extraction fails
-	}
-
 	// Create the new project entry
 	newProject := types.Project{
 		Name:         projectName,
 		Path:         projectPath,
 		LastModified: fileInfo.ModTime(),
 		Size:         fileInfo.Size(),
-		BPM:          bpm,
 	}
 
-	// Read existing projects.json if it exists
-	var projects []types.Project
-	if data, err := os.ReadFile(projectsFile); err == nil {
-		// File exists, parse it
-		if err := json.Unmarshal(data, &projects); err != nil {
-			return fmt.Errorf("failed to parse existing projects.json: %w", err)
-		}
+	if err := readProjectInfo(&newProject); err != nil {
+		log.Printf("[music-project-manager] Warning: failed to read %s: %v", projectPath, err)
 	}
-	// If file doesn't exist or is empty, projects will be an empty slice, which is fine
 
-	// Append the new project
-	projects = append(projects, newProject)
+	// A missing projects.json just means nothing has been cataloged yet
+	projects, err := loadCatalog(projectDirBase)
+	if err != nil {
+		if _, statErr := os.Stat(filepath.Join(projectDirBase, "projects.json")); statErr == nil {
+			return err
+		}
+		projects = nil
+	}
 
-	// Write back to projects.json
-	projectsData, err := json.MarshalIndent(projects, "", "  ")
+	// Include metadata already recorded in the project's sidecar, or kept in the catalog
+	meta, err := readSidecar(projectPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal projects data: %w", err)
+		log.Printf("[music-project-manager] Warning: failed to read metadata for %s: %v", projectPath, err)
 	}
 
-	if err := os.WriteFile(projectsFile, projectsData, 0o644); err != nil {
-		return fmt.Errorf("failed to write projects.json: %w", err)
+	replaced := false
+	for i, proj := range projects {
+		if filepath.Clean(proj.Path) != filepath.Clean(projectPath) {
+			continue
+		}
+		newProject.ProjectMetadata = proj.ProjectMetadata
+		if meta != nil {
+			newProject.ProjectMetadata = *meta
+		}
+		projects[i] = newProject
+		replaced = true
+		break
 	}
 
-	log.Printf("[music-project-manager] Successfully appended project '%s' to projects.json", projectName)
+	if !replaced {
+		if meta != nil {
+			newProject.ProjectMetadata = *meta
+		}
+		projects = append(projects, newProject)
+	}
+
+	if err := saveCatalog(projectDirBase, projects); err != nil {
+		return err
+	}
+
+	log.Printf("[music-project-manager] Successfully registered project '%s' in projects.json", projectName)
 	return nil
 }
 
@@ -944,6 +1382,40 @@ func updateProjectBPM(filePath string, bpm int) error {
 	return os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0o644)
 }
 
+// withTag returns tags with the single tag parameter appended when set
+func withTag(tags []string, tag string) []string {
+	if tag != "" {
+		return append(tags, tag)
+	}
+	return tags
+}
+
+// stringArrayProperty builds a JSON schema property for a list of strings
+func stringArrayProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": description,
+		"items":       map[string]interface{}{"type": "string"},
+	}
+}
+
+// booleanProperty builds a JSON schema property for a boolean flag
+func booleanProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": description,
+	}
+}
+
+// stringMapProperty builds a JSON schema property for an object of string values
+func stringMapProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"description":          description,
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}
+}
+
 // launchReaper launches Reaper with the given project file
 func launchReaper(projectPath string) error {
 	cmd := exec.Command("open", "-a", "Reaper", projectPath)
@@ -0,0 +1,200 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/johnjallday/music_project_manager/internal/types"
+)
+
+// newTestSettings returns settings with an empty project directory and a
+// template directory holding default.RPP
+func newTestSettings(t *testing.T) *types.Settings {
+	t.Helper()
+	root := t.TempDir()
+	settings := &types.Settings{
+		ProjectDir:  filepath.Join(root, "Projects"),
+		TemplateDir: filepath.Join(root, "Templates"),
+	}
+	writeTestFile(t, filepath.Join(settings.TemplateDir, "default.RPP"), constantTempoProject)
+	if err := os.MkdirAll(settings.ProjectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return settings
+}
+
+// createParams returns create_project parameters that do not launch REAPER
+func createParams(name, onConflict string) types.MusicProjectParams {
+	launch := false
+	return types.MusicProjectParams{Name: name, OnConflict: onConflict, Launch: &launch}
+}
+
+// projectFolders lists the folders in a project directory
+func projectFolders(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var folders []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			folders = append(folders, entry.Name())
+		}
+	}
+	return folders
+}
+
+func TestNewProjectConflicts(t *testing.T) {
+	settings := newTestSettings(t)
+	m := &MusicProjectManagerTool{settings: settings}
+
+	created, err := m.newProject(settings, createParams("Song", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	song := filepath.Join(settings.ProjectDir, "Song", "Song.RPP")
+	if created.Path != song || created.Existing || !exists(song) {
+		t.Fatalf("created %+v", created)
+	}
+	// A file of the user's in the existing project must survive every conflict
+	keep := filepath.Join(settings.ProjectDir, "Song", "mix notes.txt")
+	writeTestFile(t, keep, "keep me")
+
+	for _, mode := range []string{"", "error", "ERROR "} {
+		_, err := m.newProject(settings, createParams("Song", mode))
+		if err == nil || !strings.Contains(err.Error(), "already exists") || !strings.Contains(err.Error(), "'Song (2)'") {
+			t.Errorf("on_conflict %q: error = %v", mode, err)
+		}
+	}
+	if _, err := m.newProject(settings, createParams("Song", "overwrite")); err == nil || !strings.Contains(err.Error(), "on_conflict must be one of") {
+		t.Errorf("on_conflict overwrite: error = %v", err)
+	}
+
+	created, err = m.newProject(settings, createParams("Song", "suffix"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(settings.ProjectDir, "Song (2)", "Song (2).RPP"); created.Path != want || created.Name != "Song (2)" || !exists(want) {
+		t.Errorf("suffix created %+v, want %s", created, want)
+	}
+
+	created, err = m.newProject(settings, createParams("Song", "open_existing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.Existing || created.Path != song || !strings.Contains(created.Message, "already exists, using it instead") {
+		t.Errorf("open_existing returned %+v", created)
+	}
+
+	if data, err := os.ReadFile(keep); err != nil || string(data) != "keep me" {
+		t.Errorf("existing project was changed: %q, %v", data, err)
+	}
+	projects, err := loadCatalog(settings.ProjectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 {
+		t.Errorf("catalog has %d projects, want Song and Song (2): %+v", len(projects), projects)
+	}
+}
+
+func TestOpenExistingProject(t *testing.T) {
+	settings := newTestSettings(t)
+	m := &MusicProjectManagerTool{settings: settings}
+
+	// The project file may be named differently from its folder
+	beat := filepath.Join(settings.ProjectDir, "Beat", "beat old.rpp")
+	writeTestFile(t, beat, constantTempoProject)
+	created, err := m.newProject(settings, createParams("Beat", "open_existing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Path != beat || !created.Existing {
+		t.Errorf("open_existing returned %+v, want %s", created, beat)
+	}
+	projects, err := loadCatalog(settings.ProjectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].Path != beat {
+		t.Errorf("catalog = %+v, want only %s", projects, beat)
+	}
+
+	// A folder without a project is reported, not replaced
+	empty := filepath.Join(settings.ProjectDir, "Empty")
+	writeTestFile(t, filepath.Join(empty, "sample.wav"), "")
+	if _, err := m.newProject(settings, createParams("Empty", "open_existing")); err == nil || !strings.Contains(err.Error(), "contains no .RPP file") {
+		t.Errorf("error = %v", err)
+	}
+	if !exists(filepath.Join(empty, "sample.wav")) {
+		t.Error("folder without a project was changed")
+	}
+}
+
+func TestNewProjectCleanup(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, settings *types.Settings, params *types.MusicProjectParams)
+	}{
+		{"broken catalog", func(t *testing.T, settings *types.Settings, params *types.MusicProjectParams) {
+			writeTestFile(t, filepath.Join(settings.ProjectDir, "projects.json"), "{not json")
+		}},
+		{"template that is not a project", func(t *testing.T, settings *types.Settings, params *types.MusicProjectParams) {
+			writeTestFile(t, filepath.Join(settings.TemplateDir, "default.RPP"), "not a project\n")
+			params.TimeSignature = "7/8"
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, mode := range []string{"error", "suffix"} {
+			t.Run(tt.name+" on_conflict "+mode, func(t *testing.T) {
+				settings := newTestSettings(t)
+				m := &MusicProjectManagerTool{settings: settings}
+
+				// With suffix, an existing Song folder makes it create Song (2)
+				keep := filepath.Join(settings.ProjectDir, "Song", "Song.RPP")
+				if mode == "suffix" {
+					writeTestFile(t, keep, constantTempoProject)
+				}
+
+				params := createParams("Song", mode)
+				tt.setup(t, settings, &params)
+				catalog, _ := os.ReadFile(filepath.Join(settings.ProjectDir, "projects.json"))
+
+				if _, err := m.newProject(settings, params); err == nil {
+					t.Fatal("newProject succeeded")
+				}
+
+				// Only the half-created folder is removed
+				want := []string(nil)
+				if mode == "suffix" {
+					want = []string{"Song"}
+					if data, err := os.ReadFile(keep); err != nil || string(data) != constantTempoProject {
+						t.Errorf("existing project was changed: %v", err)
+					}
+				}
+				if got := projectFolders(t, settings.ProjectDir); strings.Join(got, ",") != strings.Join(want, ",") {
+					t.Errorf("project folders = %q, want %q", got, want)
+				}
+				if after, _ := os.ReadFile(filepath.Join(settings.ProjectDir, "projects.json")); string(after) != string(catalog) {
+					t.Errorf("catalog changed:\n%s", after)
+				}
+			})
+		}
+	}
+}
+
+func TestUniqueProjectName(t *testing.T) {
+	dir := t.TempDir()
+	for _, folder := range []string{"Song", "Song (2)", "Song (4)"} {
+		if err := os.Mkdir(filepath.Join(dir, folder), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := uniqueProjectName(dir, "Song"); got != "Song (3)" {
+		t.Errorf("uniqueProjectName = %q, want Song (3)", got)
+	}
+}
@@ -4,20 +4,53 @@ import "time"
 
 // MusicProjectParams defines the parameters using struct tags.
 type MusicProjectParams struct {
-	Operation string `json:"operation" description:"Music project operation: create new Reaper project, open existing project in Reaper DAW, reveal project in Finder file browser, scan for .RPP files, list projects, filter by name/BPM, or rename an existing project" enum:"create_project,scan,list_projects,open_project,open_in_finder,filter_project,rename_project" required:"true"`
-	Name      string `json:"name" description:"Project name for creating new Reaper projects, filtering existing ones, finding projects to open in Finder, or the current name of a project to rename (e.g., 'mash', 'beats', 'Rich Daddy', 'China girl EDM')"`
-	NewName   string `json:"new_name" description:"New name for the project when using rename_project operation (e.g., 'okok')"`
-	Path      string `json:"path" description:"Full file path to a Reaper project file (.RPP) to open in Reaper DAW or reveal in Finder (e.g., '/Users/name/Music/Projects/song.RPP')"`
-	BPM       int    `json:"bpm" description:"BPM for the project (optional for create_project, exact BPM for filter_project)" min:"30" max:"300"`
-	MinBPM    int    `json:"min_bpm" description:"Minimum BPM for filter_project (optional)" min:"30" max:"300"`
-	MaxBPM    int    `json:"max_bpm" description:"Maximum BPM for filter_project (optional)" min:"30" max:"300"`
+	Operation      string            `json:"operation" description:"Music project operation: create new Reaper project, create many projects at once from a YAML/JSON/CSV spec file, open existing project in Reaper DAW, reveal project in Finder file browser, scan for .RPP files, list projects, filter by name/BPM/key/length/tag, rename an existing project, add/remove/list project tags, set a project's lifecycle status, show projects grouped by status, read/update a project's metadata (collaborators, key, notes, custom fields), read, replace, append to and search the project notes stored in the .RPP file, rate and favorite projects, summarize a project's arrangement, list the media files a project uses with sample rate, bit depth, length and size, list each project's latest rendered mixdown and whether it needs a re-render, measure the loudness (integrated LUFS, true peak, loudness range) of rendered mixdowns, show a project's tempo map (tempo changes, shapes and time signature changes), change an existing project's tempo, list markers and regions, export them as cue sheet/CSV/Audacity labels/FFmpeg chapters, export MIDI items with the tempo map to Standard MIDI Files, list available project and track templates, or save an existing project as a reusable template" enum:"create_project,create_batch,scan,list_projects,open_project,open_in_finder,filter_project,rename_project,tag_project,untag_project,list_tags,set_status,status_board,get_metadata,set_metadata,get_notes,set_notes,append_notes,search_notes,rate_project,favorite_project,describe_project,check_media,list_renders,analyze_render,get_tempo_map,set_bpm,list_markers,export_markers,export_midi,list_templates,save_as_template" required:"true"`
+	Name           string            `json:"name" description:"Project name for creating new Reaper projects, filtering existing ones, finding projects to open in Finder, or the current name of a project to rename (e.g., 'mash', 'beats', 'Rich Daddy', 'China girl EDM')"`
+	TimeSignature  string            `json:"time_signature" description:"Time signature for create_project (e.g., '4/4', '6/8', '7/8')"`
+	SampleRate     int               `json:"sample_rate" description:"Project sample rate in Hz for create_project (22050, 32000, 44100, 48000, 88200, 96000, 176400 or 192000)" min:"22050" max:"192000"`
+	LengthBars     int               `json:"length_bars" description:"Default project length in bars for create_project; sets the project end marker" min:"1" max:"999"`
+	Grid           string            `json:"grid" description:"Grid division for create_project (e.g., '1/16', '1/8T' for triplets, '1/4D' for dotted)"`
+	NamingScheme   string            `json:"naming_scheme" description:"Naming scheme for create_project, overriding the configured one. Tokens: {name}, {date}, {bpm}, {key}, {n} (auto-incrementing counter, {n:3} zero-pads to 3 digits). Examples: '{date}_{name}_{bpm}bpm', 'Idea {n:3}'"`
+	OnConflict     string            `json:"on_conflict" description:"What create_project does when a project folder with the same name already exists: 'error' (default) refuses, 'suffix' creates 'Name (2)' instead, 'open_existing' opens the existing project" enum:"error,suffix,open_existing"`
+	Launch         *bool             `json:"launch" description:"Whether create_project opens the new project in REAPER (default true). Set to false to prepare projects headless or in batch"`
+	Template       string            `json:"template" description:"Template for create_project: a template name from the template directory (e.g., '808', 'Vocal Session') or a full path to a .RPP file. Uses the default template when omitted. For save_as_template, the name of the new template (defaults to the project name)"`
+	TrackTemplates []string          `json:"track_templates" description:"Track templates (.RTrackTemplate names or paths) to append to the new project's track list with create_project (e.g., ['Drum Bus', 'Vocal Chain', 'Reference'])"`
+	Description    string            `json:"description" description:"Description stored in the template's notes for save_as_template (e.g., 'Trap beat: 808, drum bus, vocal chain')"`
+	NewName        string            `json:"new_name" description:"New name for the project when using rename_project operation (e.g., 'okok')"`
+	Path           string            `json:"path" description:"Full file path to a Reaper project file (.RPP) to open in Reaper DAW, reveal in Finder, or read/edit notes in (e.g., '/Users/name/Music/Projects/song.RPP'), or to the .yaml, .json or .csv spec file for create_batch"`
+	BPM            int               `json:"bpm" description:"BPM for the project (optional for create_project, new tempo for set_bpm, exact BPM for filter_project; songs with tempo changes match any BPM in their range)" min:"30" max:"300"`
+	MinBPM         int               `json:"min_bpm" description:"Minimum BPM for filter_project (optional)" min:"30" max:"300"`
+	MaxBPM         int               `json:"max_bpm" description:"Maximum BPM for filter_project (optional)" min:"30" max:"300"`
+	Rescale        bool              `json:"rescale" description:"With set_bpm, also move items, markers and automation so the arrangement stays on the grid; audio items are time-stretched (default false)"`
+	PerTrack       bool              `json:"per_track" description:"With export_midi, write one .mid file per track instead of one multitrack file (default false)"`
+	Format         string            `json:"format" description:"File format for export_markers: cue sheet, CSV, Audacity label track, FFmpeg chapter metadata, or all of them (default)" enum:"cue,csv,audacity,ffmpeg,all"`
+	Tags           []string          `json:"tags" description:"Tags to add with tag_project, remove with untag_project, or give new projects with create_project and create_batch (e.g., ['genre:trap', 'client:acme', 'album:summer', 'mood:dark'])"`
+	Tag            string            `json:"tag" description:"Single tag for filter_project, tag_project or untag_project (e.g., 'genre:trap')"`
+	Status         string            `json:"status" description:"Lifecycle status for set_status, or status filter for filter_project" enum:"idea,writing,arranging,mixing,mastering,released,abandoned"`
+	Collaborators  []string          `json:"collaborators" description:"Collaborators to store with set_metadata (replaces the existing list)"`
+	Key            string            `json:"key" description:"Musical key for create_project (adds a key marker and fills {{KEY}} placeholders), to store with set_metadata, or to filter by with filter_project, which also matches keys detected from MIDI (e.g., 'F# minor', 'Am')"`
+	Notes          string            `json:"notes" description:"Project notes text for set_notes/append_notes (written into the .RPP, e.g. lyric ideas, todo lists, mix feedback), or plugin notes for set_metadata"`
+	Rating         *int              `json:"rating" description:"Star rating for rate_project (1-5, or 0 to clear the rating)" min:"0" max:"5"`
+	MinRating      int               `json:"min_rating" description:"Minimum star rating for list_projects and filter_project (optional)" min:"1" max:"5"`
+	Favorite       *bool             `json:"favorite" description:"Whether favorite_project marks (true, default) or unmarks (false) the project as a favorite"`
+	FavoritesOnly  bool              `json:"favorites_only" description:"Only show favorite projects in list_projects and filter_project (optional)"`
+	MinLength      int               `json:"min_length" description:"Minimum project length in seconds for filter_project (optional)"`
+	MaxLength      int               `json:"max_length" description:"Maximum project length in seconds for filter_project (optional, e.g. 30 to find short loops)"`
+	Sort           string            `json:"sort" description:"Sort order for list_projects and filter_project: most recent first (default), name, bpm, length, or rating" enum:"date,name,bpm,length,rating"`
+	Clear          bool              `json:"clear" description:"With set_notes, remove the project notes. Required to clear them; set_notes without notes text is rejected"`
+	Query          string            `json:"query" description:"Text to search for in project notes with search_notes (e.g., 'vocal too loud')"`
+	Custom         map[string]string `json:"custom" description:"Custom fields to merge with set_metadata; an empty value removes the field (e.g., {'label': 'Nightshift'})"`
 }
 
 // Settings represents the plugin configuration
 type Settings struct {
-	DefaultTemplate string `json:"default_template"`
-	ProjectDir      string `json:"project_dir"`
-	TemplateDir     string `json:"template_dir"`
+	DefaultTemplate  string `json:"default_template"`
+	ProjectDir       string `json:"project_dir"`
+	TemplateDir      string `json:"template_dir"`
+	Artist           string `json:"artist"`
+	TrackTemplateDir string `json:"track_template_dir"`
+	NamingScheme     string `json:"naming_scheme"`
+	RenderDir        string `json:"render_dir"`
 }
 
 // Project represents a music project
@@ -27,6 +60,43 @@ type Project struct {
 	LastModified time.Time `json:"lastModified"`
 	Size         int64     `json:"size"`
 	BPM          float64   `json:"bpm"`
+	MinBPM       float64   `json:"minBpm,omitempty"`
+	MaxBPM       float64   `json:"maxBpm,omitempty"`
+	TempoChanges int       `json:"tempoChanges,omitempty"`
+	Length       float64   `json:"length,omitempty"`
+	DetectedKey  string    `json:"detectedKey,omitempty"`
+	ProjectMetadata
+}
+
+// ProjectMetadata holds the plugin's own per-project data. It is stored in a
+// sidecar file inside the project folder so it travels with the project.
+type ProjectMetadata struct {
+	Tags          []string          `json:"tags,omitempty"`
+	Status        string            `json:"status,omitempty"`
+	StatusHistory []StatusChange    `json:"statusHistory,omitempty"`
+	Rating        int               `json:"rating,omitempty"`
+	Favorite      bool              `json:"favorite,omitempty"`
+	Notes         string            `json:"notes,omitempty"`
+	Collaborators []string          `json:"collaborators,omitempty"`
+	Key           string            `json:"key,omitempty"`
+	Custom        map[string]string `json:"custom,omitempty"`
+	Loudness      *RenderLoudness   `json:"loudness,omitempty"`
+}
+
+// RenderLoudness is the measured loudness of a project's latest render
+type RenderLoudness struct {
+	File       string    `json:"file"`
+	AnalyzedAt time.Time `json:"analyzedAt"`
+	Integrated float64   `json:"integratedLufs"`
+	TruePeak   float64   `json:"truePeakDbtp"`
+	Range      float64   `json:"loudnessRangeLu"`
+	Duration   float64   `json:"duration"`
+}
+
+// StatusChange records a project's transition into a lifecycle status
+type StatusChange struct {
+	Status    string    `json:"status"`
+	ChangedAt time.Time `json:"changedAt"`
 }
 
 // AgentsConfig represents the agents.json file structure